@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package diff computes the differences between two texts, word by word.
+package diff
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tokenRegexp matches runs of whitespace and runs of other characters, so
+// that the whitespace of the texts is kept in the edits.
+var tokenRegexp = regexp.MustCompile(`\s+|\S+`)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Edit is a part of text that is either common to both texts, only present
+// in the new one or only present in the old one.
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// Words returns the edits to transform the text a into b, computed from the
+// longest common subsequence of their words. Consecutive edits always have
+// different operations, and the changes only separated by whitespace are
+// grouped together.
+func Words(a, b string) []Edit {
+	tokensA := tokenRegexp.FindAllString(a, -1)
+	tokensB := tokenRegexp.FindAllString(b, -1)
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// tokensA[i:] and tokensB[j:].
+	lcs := make([][]int, len(tokensA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(tokensB)+1)
+	}
+	for i := len(tokensA) - 1; i >= 0; i-- {
+		for j := len(tokensB) - 1; j >= 0; j-- {
+			switch {
+			case tokensA[i] == tokensB[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	edits := make([]Edit, 0)
+	add := func(op Op, text string) {
+		if last := len(edits) - 1; last >= 0 && edits[last].Op == op {
+			edits[last].Text += text
+		} else {
+			edits = append(edits, Edit{op, text})
+		}
+	}
+	i, j := 0, 0
+	for i < len(tokensA) || j < len(tokensB) {
+		switch {
+		case i < len(tokensA) && j < len(tokensB) && tokensA[i] == tokensB[j]:
+			add(Equal, tokensA[i])
+			i++
+			j++
+		case j == len(tokensB) || (i < len(tokensA) && lcs[i+1][j] >= lcs[i][j+1]):
+			add(Delete, tokensA[i])
+			i++
+		default:
+			add(Insert, tokensB[j])
+			j++
+		}
+	}
+	return group(edits)
+}
+
+// group merges the changes that are only separated by whitespace, each group
+// becoming a deletion followed by an insertion.
+func group(edits []Edit) []Edit {
+	grouped := make([]Edit, 0, len(edits))
+	var deleted, inserted string
+	flush := func() {
+		if deleted != "" {
+			grouped = append(grouped, Edit{Delete, deleted})
+		}
+		if inserted != "" {
+			grouped = append(grouped, Edit{Insert, inserted})
+		}
+		deleted, inserted = "", ""
+	}
+	for i, edit := range edits {
+		switch {
+		case edit.Op == Delete:
+			deleted += edit.Text
+		case edit.Op == Insert:
+			inserted += edit.Text
+		case (deleted != "" || inserted != "") && i+1 < len(edits) && strings.TrimSpace(edit.Text) == "":
+			deleted += edit.Text
+			inserted += edit.Text
+		default:
+			flush()
+			grouped = append(grouped, edit)
+		}
+	}
+	flush()
+	return grouped
+}
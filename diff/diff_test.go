@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/diff"
+)
+
+func TestWords(t *testing.T) {
+	cases := []struct {
+		name     string
+		a        string
+		b        string
+		expected []diff.Edit
+	}{
+		{
+			"equal",
+			"The package is broken.",
+			"The package is broken.",
+			[]diff.Edit{{diff.Equal, "The package is broken."}},
+		},
+		{
+			"replace",
+			"The package is broken.",
+			"The source is broken.",
+			[]diff.Edit{
+				{diff.Equal, "The "},
+				{diff.Delete, "package"},
+				{diff.Insert, "source"},
+				{diff.Equal, " is broken."},
+			},
+		},
+		{
+			"insert",
+			"The package is broken.",
+			"The package is really broken.",
+			[]diff.Edit{
+				{diff.Equal, "The package is "},
+				{diff.Insert, "really "},
+				{diff.Equal, "broken."},
+			},
+		},
+		{
+			"delete at end",
+			"The package is broken. See foo.",
+			"The package is broken.",
+			[]diff.Edit{
+				{diff.Equal, "The package is broken."},
+				{diff.Delete, " See foo."},
+			},
+		},
+		{
+			"group",
+			"This is an old test.",
+			"This is a new test.",
+			[]diff.Edit{
+				{diff.Equal, "This is "},
+				{diff.Delete, "an old"},
+				{diff.Insert, "a new"},
+				{diff.Equal, " test."},
+			},
+		},
+		{
+			"from empty",
+			"",
+			"New text.",
+			[]diff.Edit{{diff.Insert, "New text."}},
+		},
+		{
+			"both empty",
+			"",
+			"",
+			[]diff.Edit{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := diff.Words(c.a, c.b)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
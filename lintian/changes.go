@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian
+
+import "sort"
+
+// Change is the change of a property of a tag between two lintian versions.
+type Change[T any] struct {
+	Tag  string `json:"tag"`
+	From T      `json:"from"`
+	To   T      `json:"to"`
+}
+
+// Rename is the renaming of a tag between two lintian versions.
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Changes lists the differences between the tags of two lintian versions.
+// The tags are identified by their name in the new version.
+type Changes struct {
+	From         string           `json:"from"`
+	To           string           `json:"to"`
+	Added        []string         `json:"added"`
+	Removed      []string         `json:"removed"`
+	Renamed      []Rename         `json:"renamed"`
+	Severity     []Change[Level]  `json:"severity"`
+	Experimental []Change[bool]   `json:"experimental"`
+	Explanation  []Change[string] `json:"explanation"`
+}
+
+// Empty reports whether there is no difference between the two versions.
+func (c *Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Renamed) == 0 &&
+		len(c.Severity) == 0 && len(c.Experimental) == 0 && len(c.Explanation) == 0
+}
+
+// CompareTags returns the changes between the tags of an old and a new
+// lintian version. A tag of the new version is considered as renamed if one
+// of its previous names is the name of a tag of the old version.
+func CompareTags(oldTags []*Tag, newTags []*Tag) *Changes {
+	changes := &Changes{
+		Added:        make([]string, 0),
+		Removed:      make([]string, 0),
+		Renamed:      make([]Rename, 0),
+		Severity:     make([]Change[Level], 0),
+		Experimental: make([]Change[bool], 0),
+		Explanation:  make([]Change[string], 0),
+	}
+	oldByName := make(map[string]*Tag, len(oldTags))
+	for _, tag := range oldTags {
+		oldByName[tag.Name] = tag
+	}
+	matched := make(map[string]bool, len(oldTags))
+	for _, tag := range newTags {
+		prev := oldByName[tag.Name]
+		if prev == nil {
+			for _, name := range tag.RenamedFrom {
+				if renamed := oldByName[name]; renamed != nil && !matched[name] {
+					prev = renamed
+					changes.Renamed = append(changes.Renamed, Rename{name, tag.Name})
+					break
+				}
+			}
+		}
+		if prev == nil {
+			changes.Added = append(changes.Added, tag.Name)
+			continue
+		}
+		matched[prev.Name] = true
+		if prev.Visibility != tag.Visibility {
+			changes.Severity = append(changes.Severity, Change[Level]{tag.Name, prev.Visibility, tag.Visibility})
+		}
+		if prev.Experimental != tag.Experimental {
+			changes.Experimental = append(changes.Experimental, Change[bool]{tag.Name, prev.Experimental, tag.Experimental})
+		}
+		if prev.Explanation != tag.Explanation {
+			changes.Explanation = append(changes.Explanation, Change[string]{tag.Name, prev.Explanation, tag.Explanation})
+		}
+	}
+	for _, tag := range oldTags {
+		if !matched[tag.Name] {
+			changes.Removed = append(changes.Removed, tag.Name)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Slice(changes.Renamed, func(i, j int) bool {
+		return changes.Renamed[i].To < changes.Renamed[j].To
+	})
+	sort.Slice(changes.Severity, func(i, j int) bool {
+		return changes.Severity[i].Tag < changes.Severity[j].Tag
+	})
+	sort.Slice(changes.Experimental, func(i, j int) bool {
+		return changes.Experimental[i].Tag < changes.Experimental[j].Tag
+	})
+	sort.Slice(changes.Explanation, func(i, j int) bool {
+		return changes.Explanation[i].Tag < changes.Explanation[j].Tag
+	})
+	return changes
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+func TestCompareTags(t *testing.T) {
+	oldTags := []*lintian.Tag{
+		{Name: "same-tag", Visibility: lintian.LevelInfo, Explanation: "Same."},
+		{Name: "removed-tag", Visibility: lintian.LevelInfo},
+		{Name: "old-name", Visibility: lintian.LevelInfo},
+		{Name: "changed-tag", Visibility: lintian.LevelInfo, Explanation: "Old text."},
+	}
+	newTags := []*lintian.Tag{
+		{Name: "same-tag", Visibility: lintian.LevelInfo, Explanation: "Same."},
+		{Name: "added-tag", Visibility: lintian.LevelError},
+		{Name: "new-name", Visibility: lintian.LevelInfo, RenamedFrom: []string{"old-name"}},
+		{Name: "changed-tag", Visibility: lintian.LevelWarning, Experimental: true, Explanation: "New text."},
+	}
+	expected := &lintian.Changes{
+		Added:        []string{"added-tag"},
+		Removed:      []string{"removed-tag"},
+		Renamed:      []lintian.Rename{{From: "old-name", To: "new-name"}},
+		Severity:     []lintian.Change[lintian.Level]{{Tag: "changed-tag", From: lintian.LevelInfo, To: lintian.LevelWarning}},
+		Experimental: []lintian.Change[bool]{{Tag: "changed-tag", From: false, To: true}},
+		Explanation:  []lintian.Change[string]{{Tag: "changed-tag", From: "Old text.", To: "New text."}},
+	}
+	actual := lintian.CompareTags(oldTags, newTags)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+	if !lintian.CompareTags(oldTags, oldTags).Empty() {
+		t.Error("expected no changes between the same tags")
+	}
+}
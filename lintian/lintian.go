@@ -12,7 +12,10 @@ import (
 	"github.com/n-peugnet/lintian-ssg/markdown"
 )
 
-const sourceURLFmt = "https://salsa.debian.org/lintian/lintian/-/blob/%s/tags/%s.tag"
+const (
+	sourceURLFmt      = "https://salsa.debian.org/lintian/lintian/-/blob/%s/tags/%s.tag"
+	checkSourceURLFmt = "https://salsa.debian.org/lintian/lintian/-/blob/%s/lib/Lintian/Check/%s.pm"
+)
 
 type Screen struct {
 	Advocates []string `json:"advocates"`
@@ -43,10 +46,21 @@ const (
 	LevelClassification Level = "classification"
 )
 
+// Levels lists all the levels, sorted by decreasing severity.
+var Levels = []Level{
+	LevelError,
+	LevelWarning,
+	LevelInfo,
+	LevelPedantic,
+	LevelClassification,
+}
+
 type Tag struct {
 	Name           string   `json:"name"`
 	NameSpaced     bool     `json:"name_spaced"`
 	Visibility     Level    `json:"visibility"`
+	Check          string   `json:"check"`
+	ShowAlways     bool     `json:"show_always"`
 	Explanation    string   `json:"explanation"`
 	SeeAlso        []string `json:"see_also"`
 	RenamedFrom    []string `json:"renamed_from"`
@@ -78,3 +92,23 @@ func (t *Tag) Source() string {
 	}
 	return fmt.Sprintf(sourceURLFmt, t.LintianVersion, name)
 }
+
+// CheckSource returns the URL of the Perl module of the check that emits
+// this tag. For instance the check "files/permissions/usr-lib" is implemented
+// in "lib/Lintian/Check/Files/Permissions/UsrLib.pm".
+func (t *Tag) CheckSource() string {
+	if t.Check == "" {
+		return ""
+	}
+	parts := strings.Split(t.Check, "/")
+	for i, part := range parts {
+		words := strings.Split(part, "-")
+		for j, word := range words {
+			if word != "" {
+				words[j] = strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+		parts[i] = strings.Join(words, "")
+	}
+	return fmt.Sprintf(checkSourceURLFmt, t.LintianVersion, path.Join(parts...))
+}
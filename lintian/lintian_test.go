@@ -41,6 +41,27 @@ func TestSource(t *testing.T) {
 	}
 }
 
+func TestCheckSource(t *testing.T) {
+	cases := []struct {
+		check    string
+		expected string
+	}{
+		{"", ""},
+		{"files/permissions/usr-lib", "https://salsa.debian.org/lintian/lintian/-/blob//lib/Lintian/Check/Files/Permissions/UsrLib.pm"},
+		{"debian/changelog", "https://salsa.debian.org/lintian/lintian/-/blob//lib/Lintian/Check/Debian/Changelog.pm"},
+		{"teams/js", "https://salsa.debian.org/lintian/lintian/-/blob//lib/Lintian/Check/Teams/Js.pm"},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, c.check), func(t *testing.T) {
+			tag := lintian.Tag{Check: c.check}
+			actual := tag.CheckSource()
+			if actual != c.expected {
+				t.Fatalf("\nexpected: %q\nactual  : %q", c.expected, actual)
+			}
+		})
+	}
+}
+
 func TestSeeAlsoHTML(t *testing.T) {
 	tag := lintian.Tag{
 		SeeAlso: []string{
@@ -51,7 +72,7 @@ func TestSeeAlsoHTML(t *testing.T) {
 		},
 	}
 	expected := []string{
-		"<p><a href=\"https://www.debian.org/doc/debian-policy/ch-opersys.html#file-system-structure\">File System Structure</a> (Section 9.1.1) in the Debian Policy Manual</p>\n",
+		"<p><a href=\"https://www.debian.org/doc/debian-policy/ch-opersys.html#file-system-structure\">File System Structure</a> (<a href=\"https://www.debian.org/doc/debian-policy/ch-opersys.html#s9.1.1\">Section 9.1.1</a>) in the Debian Policy Manual</p>\n",
 		"<p>filesystem-hierarchy</p>\n",
 		"<p><a href=\"https://refspecs.linuxfoundation.org/FHS_3.0/fhs/ch04s07.html\">https://refspecs.linuxfoundation.org/FHS_3.0/fhs/ch04s07.html</a></p>\n",
 		"<p><a href=\"https://bugs.debian.org/954149\">Bug#954149</a></p>\n",
@@ -72,6 +93,7 @@ func TestUnmarshal(t *testing.T) {
 		{ // lintian v2.118.0
 			"lintian_2.118.0_executable-in-usr-lib",
 			lintian.Tag{
+				Check:          "files/permissions/usr-lib",
 				Experimental:   true,
 				Explanation:    "The package ships an executable file in /usr/lib.\n\nPlease move the file to <code>/usr/libexec</code>.\n\nWith policy revision 4.1.5, Debian adopted the Filesystem\nHierarchy Specification (FHS) version 3.0.\n\nThe FHS 3.0 describes <code>/usr/libexec</code>. Please use that\nlocation for executables.",
 				LintianVersion: "2.118.0",
@@ -104,6 +126,7 @@ func TestUnmarshal(t *testing.T) {
 					"<https://refspecs.linuxfoundation.org/FHS_3.0/fhs/ch04s07.html>",
 					"[Bug#954149](https://bugs.debian.org/954149)",
 				},
+				ShowAlways: false,
 				Visibility: lintian.LevelPedantic,
 			},
 		},
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian
+
+import (
+	"regexp"
+	"sort"
+)
+
+// nameRegexp matches the words that could be a tag name.
+var nameRegexp = regexp.MustCompile(`[A-Za-z0-9][A-Za-z0-9+._/-]*[A-Za-z0-9+]`)
+
+// References is an index of the mentions of tag names inside other tags.
+type References struct {
+	names        map[string]bool
+	referencedBy map[string][]string
+}
+
+// NewReferences searches the given tags for mentions of the names of the
+// other tags, in their explanation, see also and screens.
+func NewReferences(tags []*Tag) *References {
+	r := &References{
+		names:        make(map[string]bool, len(tags)),
+		referencedBy: make(map[string][]string),
+	}
+	for _, tag := range tags {
+		r.names[tag.Name] = true
+	}
+	for _, tag := range tags {
+		for _, name := range r.Mentions(tag.texts()...) {
+			if name != tag.Name {
+				r.referencedBy[name] = append(r.referencedBy[name], tag.Name)
+			}
+		}
+	}
+	for _, names := range r.referencedBy {
+		sort.Strings(names)
+	}
+	return r
+}
+
+// Has reports whether name is the name of a known tag.
+func (r *References) Has(name string) bool {
+	return r.names[name]
+}
+
+// ReferencedBy returns the sorted names of the tags that mention name.
+func (r *References) ReferencedBy(name string) []string {
+	return r.referencedBy[name]
+}
+
+// Mentions returns the names of the known tags mentioned in texts, in order
+// of first appearance.
+func (r *References) Mentions(texts ...string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, word := range nameRegexp.FindAllString(text, -1) {
+			if r.names[word] && !seen[word] {
+				seen[word] = true
+				mentions = append(mentions, word)
+			}
+		}
+	}
+	return mentions
+}
+
+// texts returns all the texts of the tag that can mention other tags.
+func (t *Tag) texts() []string {
+	texts := make([]string, 0, 1+len(t.SeeAlso)+2*len(t.Screens))
+	texts = append(texts, t.Explanation)
+	texts = append(texts, t.SeeAlso...)
+	for _, screen := range t.Screens {
+		texts = append(texts, screen.Reason)
+		texts = append(texts, screen.SeeAlso...)
+	}
+	return texts
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+func TestReferences(t *testing.T) {
+	tags := []*lintian.Tag{
+		{
+			Name:        "tag-a",
+			Explanation: "See <code>tag-b</code> and teams/js/tag-c. Not tag-a or tag-d.",
+		},
+		{
+			Name:    "tag-b",
+			SeeAlso: []string{"tag-a", "tag-b"},
+		},
+		{
+			Name: "teams/js/tag-c",
+			Screens: []lintian.Screen{
+				{Reason: "Unlike tag-b.", SeeAlso: []string{"tag-a"}},
+			},
+		},
+	}
+	refs := lintian.NewReferences(tags)
+	cases := []struct {
+		name     string
+		expected []string
+	}{
+		{"tag-a", []string{"tag-b", "teams/js/tag-c"}},
+		{"tag-b", []string{"tag-a", "teams/js/tag-c"}},
+		{"teams/js/tag-c", []string{"tag-a"}},
+		{"tag-d", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := refs.ReferencedBy(c.name)
+			if !reflect.DeepEqual(c.expected, actual) {
+				t.Fatalf("\nexpected: %q\nactual  : %q", c.expected, actual)
+			}
+		})
+	}
+	if !refs.Has("tag-a") || refs.Has("tag-d") {
+		t.Error("unexpected result of Has")
+	}
+	mentions := refs.Mentions("tag-b, tag-a.", "tag-b")
+	if expected := []string{"tag-b", "tag-a"}; !reflect.DeepEqual(expected, mentions) {
+		t.Errorf("\nexpected: %q\nactual  : %q", expected, mentions)
+	}
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Source is a source of lintian tags.
+type Source interface {
+	// Next returns the next tag of the source, or io.EOF if there is none left.
+	Next() (*Tag, error)
+	// Close releases the resources held by the source.
+	Close() error
+}
+
+// JSONSource is a Source that decodes a stream containing a JSON array of
+// tags, as produced by "lintian-explain-tags --format=json".
+type JSONSource struct {
+	decoder *json.Decoder
+	closer  io.Closer
+	started bool
+	done    bool
+}
+
+// NewJSONSource returns a new JSONSource that reads from r.
+func NewJSONSource(r io.Reader) *JSONSource {
+	return &JSONSource{decoder: json.NewDecoder(r)}
+}
+
+// OpenJSONFile returns a new JSONSource that reads from the named file.
+// If name is "-", the standard input is used instead.
+func OpenJSONFile(name string) (*JSONSource, error) {
+	if name == "-" {
+		return NewJSONSource(os.Stdin), nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	source := NewJSONSource(file)
+	source.closer = file
+	return source, nil
+}
+
+func (s *JSONSource) expectDelim(expected json.Delim) error {
+	token, err := s.decoder.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != expected {
+		return fmt.Errorf("expected %q, got: %v", expected, token)
+	}
+	return nil
+}
+
+func (s *JSONSource) Next() (*Tag, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	if !s.started {
+		if err := s.expectDelim('['); err != nil {
+			return nil, err
+		}
+		s.started = true
+	}
+	if !s.decoder.More() {
+		if err := s.expectDelim(']'); err != nil {
+			return nil, err
+		}
+		s.done = true
+		return nil, io.EOF
+	}
+	tag := &Tag{}
+	if err := s.decoder.Decode(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *JSONSource) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// CommandSource is a Source that reads the JSON output of a running
+// "lintian-explain-tags" command.
+type CommandSource struct {
+	*JSONSource
+	cmd *exec.Cmd
+	out io.Reader
+}
+
+// StartExplainTags starts "lintian-explain-tags --format=json" and returns
+// a CommandSource that decodes its output. Its standard error is forwarded to
+// the one of the current process.
+func StartExplainTags() (*CommandSource, error) {
+	cmd := exec.Command("lintian-explain-tags", "--format=json")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &CommandSource{NewJSONSource(out), cmd, out}, nil
+}
+
+// Close waits for the command to exit, and returns its error if any. The rest
+// of its output is discarded, so that it does not stay blocked writing it
+// when the decoding stopped early.
+func (s *CommandSource) Close() error {
+	io.Copy(io.Discard, s.out)
+	return s.cmd.Wait()
+}
+
+// ProcessState returns information about the exited command.
+// It is only available after a call to Close.
+func (s *CommandSource) ProcessState() *os.ProcessState {
+	return s.cmd.ProcessState
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+func readAll(source lintian.Source) ([]string, error) {
+	names := make([]string, 0)
+	for {
+		tag, err := source.Next()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return names, err
+		}
+		names = append(names, tag.Name)
+	}
+}
+
+func TestJSONSource(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+		err      string
+	}{
+		{`[]`, []string{}, ""},
+		{`[{"name":"a"},{"name":"b"}]`, []string{"a", "b"}, ""},
+		{``, []string{}, io.ErrUnexpectedEOF.Error()},
+		{`[{"name":"a"}`, []string{"a"}, "unexpected end of JSON input"},
+		{`{"name":"a"}`, []string{}, `expected "[", got: {`},
+		{`[{"name":1}]`, []string{}, "cannot unmarshal number"},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, c.input), func(t *testing.T) {
+			source := lintian.NewJSONSource(strings.NewReader(c.input))
+			actual, err := readAll(source)
+			if c.err == "" && err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+				t.Fatalf("expected error containing %q, got: %v", c.err, err)
+			}
+			if fmt.Sprint(actual) != fmt.Sprint(c.expected) {
+				t.Fatalf("\nexpected: %v\nactual  : %v", c.expected, actual)
+			}
+			if _, err := source.Next(); c.err == "" && err != io.EOF {
+				t.Fatal("expected io.EOF after the end, got:", err)
+			}
+		})
+	}
+}
+
+func TestOpenJSONFile(t *testing.T) {
+	source, err := lintian.OpenJSONFile(filepath.Join("testdata", "tags.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := readAll(source)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	expected := []string{"executable-in-usr-lib", "teams/js/test-tag"}
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Fatalf("\nexpected: %v\nactual  : %v", expected, actual)
+	}
+	if err := source.Close(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+func TestOpenJSONFileNotFound(t *testing.T) {
+	_, err := lintian.OpenJSONFile(filepath.Join("testdata", "not-found.json"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal("expected ErrNotExist, got:", err)
+	}
+}
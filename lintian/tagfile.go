@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// changelogRegexp matches the first line of lintian's debian/changelog.
+var changelogRegexp = regexp.MustCompile(`^lintian \(([^)]+)\)`)
+
+// DirSource is a Source that reads the tags from the "tags" directory of a
+// lintian source tree, where each tag is described by a deb822 .tag file.
+type DirSource struct {
+	paths   []string
+	version string
+	date    time.Time
+}
+
+// OpenSourceTree returns a new DirSource for the lintian source tree located
+// at root. The lintian version and its date are read from its
+// debian/changelog, if present.
+func OpenSourceTree(root string) (*DirSource, error) {
+	source := &DirSource{}
+	err := filepath.WalkDir(filepath.Join(root, "tags"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".tag") {
+			source.paths = append(source.paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	source.version, source.date, err = readChangelog(filepath.Join(root, "debian", "changelog"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return source, nil
+}
+
+// readChangelog returns the version and the date of the first entry of
+// lintian's debian/changelog. The date is read from the trailer line of the
+// entry, and is left zero if it is missing.
+func readChangelog(path string) (version string, date time.Time, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return "", time.Time{}, fmt.Errorf("%s: unexpected empty file", path)
+	}
+	line := scanner.Text()
+	match := changelogRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return "", time.Time{}, fmt.Errorf("%s: unexpected first line: %q", path, line)
+	}
+	version = match[1]
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, " -- ") {
+			continue
+		}
+		_, value, found := strings.Cut(line, ">  ")
+		if !found {
+			break
+		}
+		date, err = time.Parse(time.RFC1123Z, strings.TrimSpace(value))
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("%s: %w", path, err)
+		}
+		break
+	}
+	return version, date, scanner.Err()
+}
+
+func (s *DirSource) Next() (*Tag, error) {
+	if len(s.paths) == 0 {
+		return nil, io.EOF
+	}
+	path := s.paths[0]
+	s.paths = s.paths[1:]
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	tag, err := ParseTagFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	tag.LintianVersion = s.version
+	return tag, nil
+}
+
+// Date returns the date of the lintian version of the source tree, or the zero
+// time if it is unknown.
+func (s *DirSource) Date() time.Time {
+	return s.date
+}
+
+func (s *DirSource) Close() error {
+	return nil
+}
+
+// ParseTagFile parses a lintian deb822 .tag file. As the screens are only
+// referenced by name in these files, only the Name of each Screen is set.
+func ParseTagFile(r io.Reader) (*Tag, error) {
+	fields, err := parseDeb822(r)
+	if err != nil {
+		return nil, err
+	}
+	tag := &Tag{
+		Name:         fields["tag"],
+		NameSpaced:   fields["name-spaced"] == "yes",
+		Visibility:   Level(fields["severity"]),
+		Check:        fields["check"],
+		ShowAlways:   fields["show-always"] == "yes",
+		Explanation:  parseText(fields["explanation"]),
+		SeeAlso:      parseSeeAlso(fields["see-also"]),
+		RenamedFrom:  strings.Fields(fields["renamed-from"]),
+		Experimental: fields["experimental"] == "yes",
+	}
+	if tag.Name == "" {
+		return nil, fmt.Errorf("missing Tag field")
+	}
+	if tag.NameSpaced {
+		tag.Name = fields["check"] + "/" + tag.Name
+	}
+	for _, name := range splitList(fields["screen"] + "," + fields["screens"]) {
+		tag.Screens = append(tag.Screens, Screen{Name: name})
+	}
+	return tag, nil
+}
+
+// parseDeb822 parses a single deb822 paragraph into a map of fields indexed
+// by their lowercased name. The continuation lines of multiline fields are
+// kept as is, including their leading space.
+func parseDeb822(r io.Reader) (map[string]string, error) {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	var current string
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.TrimSpace(line) == "":
+			if len(fields) != 0 {
+				return fields, nil
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			if current == "" {
+				return nil, fmt.Errorf("line %d: unexpected continuation line", lineNum)
+			}
+			fields[current] += "\n" + line
+		default:
+			name, value, found := strings.Cut(line, ":")
+			if !found {
+				return nil, fmt.Errorf("line %d: missing colon in field", lineNum)
+			}
+			current = strings.ToLower(name)
+			fields[current] = strings.TrimSpace(value)
+		}
+	}
+	return fields, scanner.Err()
+}
+
+// parseText returns the text of a multiline field, in which the first space
+// of each continuation line has been removed and the lines containing a single
+// dot have been replaced by empty lines.
+func parseText(value string) string {
+	lines := strings.Split(value, "\n")
+	for i, line := range lines[1:] {
+		line = line[1:]
+		if line == "." {
+			line = ""
+		}
+		lines[i+1] = line
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// parseSeeAlso returns the references of a See-Also field, in which URLs are
+// enclosed in angle brackets to be rendered as links.
+func parseSeeAlso(value string) []string {
+	refs := splitList(value)
+	for i, ref := range refs {
+		if strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://") {
+			refs[i] = "<" + ref + ">"
+		}
+	}
+	return refs
+}
+
+// splitList returns the non-empty elements of a comma separated list.
+func splitList(value string) []string {
+	var list []string
+	for _, elem := range strings.Split(value, ",") {
+		elem = strings.Join(strings.Fields(elem), " ")
+		if elem != "" {
+			list = append(list, elem)
+		}
+	}
+	return list
+}
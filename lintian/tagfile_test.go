@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian_test
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+func TestOpenSourceTree(t *testing.T) {
+	source, err := lintian.OpenSourceTree(filepath.Join("testdata", "lintian"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	expected := []*lintian.Tag{
+		{
+			Name:         "executable-in-usr-lib",
+			Visibility:   lintian.LevelPedantic,
+			Check:        "files/permissions/usr-lib",
+			Experimental: true,
+			Explanation:  "The package ships an executable file in /usr/lib.\n\nPlease move the file to <code>/usr/libexec</code>.\n\nWith policy revision 4.1.5, Debian adopted the Filesystem\nHierarchy Specification (FHS) version 3.0.\n\nThe FHS 3.0 describes <code>/usr/libexec</code>. Please use that\nlocation for executables.",
+			SeeAlso: []string{
+				"debian-policy 9.1.1",
+				"filesystem-hierarchy",
+				"<https://refspecs.linuxfoundation.org/FHS_3.0/fhs/ch04s07.html>",
+				"Bug#954149",
+			},
+			RenamedFrom:    []string{},
+			LintianVersion: "2.118.0",
+			Screens: []lintian.Screen{
+				{Name: "emacs/elpa/scripts"},
+				{Name: "web/cgi/scripts"},
+			},
+		},
+		{
+			Name:           "teams/js/test-tag",
+			NameSpaced:     true,
+			Visibility:     lintian.LevelInfo,
+			Check:          "teams/js",
+			ShowAlways:     true,
+			Explanation:    "This is a test.\n\n code block",
+			RenamedFrom:    []string{"old-test-tag", "older-test-tag"},
+			LintianVersion: "2.118.0",
+		},
+	}
+	for i, e := range expected {
+		actual, err := source.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(e, actual) {
+			t.Errorf("%d:\nexpected: %#v\nactual  : %#v", i, e, actual)
+		}
+	}
+	if _, err := source.Next(); err != io.EOF {
+		t.Fatal("expected io.EOF, got:", err)
+	}
+	expectedDate := time.Date(2024, time.July, 20, 10, 0, 0, 0, time.UTC)
+	if date := source.Date(); !date.Equal(expectedDate) {
+		t.Errorf("expected date %v, got: %v", expectedDate, date)
+	}
+}
+
+func TestOpenSourceTreeNotFound(t *testing.T) {
+	_, err := lintian.OpenSourceTree(filepath.Join("testdata", "not-found"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseTagFileErrors(t *testing.T) {
+	cases := []struct {
+		src string
+		err string
+	}{
+		{" continuation", "line 1: unexpected continuation line"},
+		{"Tag test-tag", "line 1: missing colon in field"},
+		{"Severity: info", "missing Tag field"},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, c.src), func(t *testing.T) {
+			_, err := lintian.ParseTagFile(strings.NewReader(c.src))
+			if err == nil || err.Error() != c.err {
+				t.Fatalf("expected error %q, got: %v", c.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two Debian package versions following the algorithm
+// of dpkg. The result is negative if a < b, zero if a == b and positive if
+// a > b.
+func CompareVersions(a, b string) int {
+	epochA, upstreamA, revisionA := splitVersion(a)
+	epochB, upstreamB, revisionB := splitVersion(b)
+	if epochA != epochB {
+		return epochA - epochB
+	}
+	if cmp := compareVersionPart(upstreamA, upstreamB); cmp != 0 {
+		return cmp
+	}
+	return compareVersionPart(revisionA, revisionB)
+}
+
+// splitVersion splits a Debian version into its epoch, upstream version and
+// Debian revision.
+func splitVersion(v string) (epoch int, upstream string, revision string) {
+	if before, after, found := strings.Cut(v, ":"); found {
+		epoch, _ = strconv.Atoi(before)
+		v = after
+	}
+	if i := strings.LastIndexByte(v, '-'); i != -1 {
+		return epoch, v[:i], v[i+1:]
+	}
+	return epoch, v, ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// charOrder returns the weight of the character at the start of s in the non
+// digit parts of a version: "~" sorts before everything, even the end of the
+// part, and letters sort before the other characters.
+func charOrder(s string) int {
+	switch {
+	case s == "" || isDigit(s[0]):
+		return 0
+	case isLetter(s[0]):
+		return int(s[0])
+	case s[0] == '~':
+		return -1
+	default:
+		return int(s[0]) + 256
+	}
+}
+
+// compareVersionPart compares the upstream versions or the Debian revisions of
+// two versions, alternating between non digit and digit parts.
+func compareVersionPart(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			if cmp := charOrder(a) - charOrder(b); cmp != 0 {
+				return cmp
+			}
+			if a != "" {
+				a = a[1:]
+			}
+			if b != "" {
+				b = b[1:]
+			}
+		}
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+		firstDiff := 0
+		for a != "" && isDigit(a[0]) && b != "" && isDigit(b[0]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[0]) - int(b[0])
+			}
+			a = a[1:]
+			b = b[1:]
+		}
+		if a != "" && isDigit(a[0]) {
+			return 1
+		}
+		if b != "" && isDigit(b[0]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package lintian_test
+
+import (
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"2.118.0", "2.118.0", 0},
+		{"2.118.0", "2.117.0", 1},
+		{"2.9.0", "2.10.0", -1},
+		{"2.116.3", "2.116.3+deb12u1", -1},
+		{"2.116.3~bpo12+1", "2.116.3", -1},
+		{"1:1.0", "2.0", 1},
+		{"1.0-1", "1.0-2", -1},
+		{"1.0-10", "1.0-9", 1},
+		{"1.0a", "1.0+", -1},
+		{"1.01", "1.1", 0},
+		{"1.0", "1.0.0", -1},
+	}
+	for _, c := range cases {
+		t.Run(c.a+"_"+c.b, func(t *testing.T) {
+			actual := lintian.CompareVersions(c.a, c.b)
+			if (actual < 0) != (c.expected < 0) || (actual > 0) != (c.expected > 0) {
+				t.Errorf("expected %d, got %d", c.expected, actual)
+			}
+		})
+	}
+}
@@ -18,140 +18,155 @@
 package main
 
 import (
-	"bytes"
-	_ "embed"
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"html/template"
 	"io"
 	"log"
 	"os"
-	"os/exec"
-	"path"
-	"path/filepath"
-	"sort"
+	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
-	"github.com/n-peugnet/lintian-ssg/ioutil"
-	"github.com/n-peugnet/lintian-ssg/lintian"
-	"github.com/n-peugnet/lintian-ssg/markdown"
+	"github.com/n-peugnet/lintian-ssg/markdown/goldmark_ext"
+	"github.com/n-peugnet/lintian-ssg/output"
+	"github.com/n-peugnet/lintian-ssg/ssg"
 	"github.com/n-peugnet/lintian-ssg/version"
 )
 
+// Exit codes of the program.
 const (
-	manualPath   = "/usr/share/doc/lintian/lintian.html"
-	sourceURLFmt = "https://salsa.debian.org/lintian/lintian/-/blob/%s/tags/%s.tag"
+	ExitOK      = 0
+	ExitFailure = 1 // unexpected error
+	ExitUsage   = 2 // invalid command line, as for the flag package
+	ExitInput   = 3 // error while reading the inputs: tags or manual
+	ExitOutput  = 4 // error while writing the website
 )
 
-type tmplParams struct {
-	DateYear       int
-	DateHuman      string
-	DateMachine    string
-	BaseURL        string
-	Root           string
-	Version        string
-	VersionLintian string
-	FooterHTML     template.HTML
-}
-
-type indexTmplParams struct {
-	tmplParams
-	TagList []string
-}
-
-type manualTmplParams struct {
-	tmplParams
-	Manual template.HTML
-}
-
-type tagTmplParams struct {
-	tmplParams
-	*lintian.Tag
-	PrevName string
-}
-
-var (
-	//go:embed templates/index.html.tmpl
-	indexTmplStr string
-	//go:embed templates/tag.html.tmpl
-	tagTmplStr string
-	//go:embed templates/renamed.html.tmpl
-	renamedTmplStr string
-	//go:embed templates/manual.html.tmpl
-	manualTmplStr string
-	//go:embed templates/about.html.tmpl
-	aboutTmplStr string
-	//go:embed templates/404.html.tmpl
-	e404TmplStr string
-	//go:embed assets/main.css
-	mainCSS []byte
-	//go:embed assets/openlogo-50.svg
-	logoSVG []byte
-	//go:embed assets/favicon.ico
-	faviconICO []byte
-
-	start = time.Now()
-)
-
-var (
-	flagBaseURL   string
-	flagFooter    string
-	flagHelp      bool
-	flagNoSitemap bool
-	flagOutDir    string
-	flagStats     bool
-	flagVersion   bool
-)
+var start = time.Now()
 
 const (
+	flagAssetsHelp = `Path of a directory of files to copy in the website, replacing the
+        embedded assets of the same name, such as main.css.`
+	flagAtomicHelp = `Generate the website in a new directory, next to the output one,
+        then atomically replace the output directory by a symlink to it.`
 	flagBaseURLHelp = `URL, including the scheme, where the root of the website will be located.
         This will be used in the sitemap and in the canonical URL of each page.`
+	flagDebianCSSHelp = `Path of a copy of https://www.debian.org/debian.css to include in the
+        website, and link to instead, so that it does not depend on external
+        resources.`
+	flagDevrefURLHelp = "Base URL of the Debian Developer's Reference, used to link its sections."
 	flagFooterHelp    = "Text to add to the footer, inline Markdown elements will be parsed."
 	flagHelpHelp      = "Show this help and exit."
-	flagNoSitemapHelp = "Disable sitemap.txt generation."
-	flagOutDirHelp    = "Path of the directory where to output the generated website."
-	flagOutDirDef     = "out"
+	flagInputHelp     = `Path of a JSON file containing the tags, as produced by
+        lintian-explain-tags --format=json, or - to read it from stdin.
+        It can also be the path of a lintian source tree, in which case
+        the tags are parsed from its tags/*/*.tag files.
+        It can be given multiple times to build a website for each lintian
+        version, in <version>/ directories, the newest one also being
+        available in latest/. An Atom feed of the added and changed tags
+        is then written in feed.xml if --base-url is set.
+        By default lintian-explain-tags is run.`
+	flagJobsHelp = `Maximum number of tags rendered concurrently, defaults to the number
+        of CPUs. A negative value removes the limit.`
+	flagKeepBuildsHelp = "Number of previous builds to keep for rollback, with --atomic."
+	flagNoSitemapHelp  = "Disable sitemap.txt generation."
+	flagOutDirHelp     = "Path of the directory where to output the generated website."
+	flagOutputHelp     = `Path where to output the generated website, as a gzipped tarball if
+        it ends with .tar.gz or .tgz, a tarball with .tar, a zip archive
+        with .zip, or a directory otherwise. It overrides --output-dir.`
+	flagPolicyURLHelp = "Base URL of the Debian Policy Manual, used to link its sections."
+	flagServeHelp     = `Build the website in memory and serve it over HTTP on the given address,
+        such as localhost:8080, instead of writing it. The pages are reloaded
+        when the inputs, the manual, the templates or the assets change.`
 	flagStatsHelp     = "Display some statistics."
-	flagVersionHelp   = "Show version and exit."
+	flagTemplatesHelp = `Path of a directory of templates overriding the embedded ones of the
+        same name, such as tag.html.tmpl. The other *.html.tmpl files define
+        the layout block of the same name, such as head-extra.html.tmpl.`
+	flagVersionHelp = "Show version and exit."
+	flagWatchHelp   = `Keep running after generating the website, and update it when the
+        inputs, the manual, the templates or the assets change, only
+        rendering the affected pages.`
 )
 
-func usage() {
-	var output io.Writer
-	if flagHelp {
-		output = os.Stdout
-	} else {
-		output = flag.CommandLine.Output()
-	}
+func usage(output io.Writer) {
 	fmt.Fprintf(output, `Usage of lintian-ssg:
+  --assets string
+        %s
+  --atomic
+        %s
   --base-url string
         %s
+  --debian-css string
+        %s
+  --devref-url string
+        %s (default %q)
   --footer string
         %s
   -h, --help
         %s
+  --input string
+        %s
+  --jobs int
+        %s
+  --keep-builds int
+        %s
   --no-sitemap
         %s
   -o, --output-dir string
         %s (default %q)
+  --output string
+        %s
+  --policy-url string
+        %s (default %q)
+  --serve string
+        %s
   --stats
         %s
+  --templates string
+        %s
   --version
         %s
+  --watch
+        %s
 `,
+		flagAssetsHelp,
+		flagAtomicHelp,
 		flagBaseURLHelp,
+		flagDebianCSSHelp,
+		flagDevrefURLHelp, goldmark_ext.DefaultDevrefURL,
 		flagFooterHelp,
 		flagHelpHelp,
+		flagInputHelp,
+		flagJobsHelp,
+		flagKeepBuildsHelp,
 		flagNoSitemapHelp,
-		flagOutDirHelp, flagOutDirDef,
+		flagOutDirHelp, ssg.DefaultOutDir,
+		flagOutputHelp,
+		flagPolicyURLHelp, goldmark_ext.DefaultPolicyURL,
+		flagServeHelp,
 		flagStatsHelp,
+		flagTemplatesHelp,
 		flagVersionHelp,
+		flagWatchHelp,
 	)
 }
 
+// stringsFlag is a flag.Value that collects the values of a flag given
+// multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -159,273 +174,219 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
-func rootRelPath(dir string) string {
-	count := strings.Count(dir, "/")
-	if count == 0 {
-		return "./"
+// closeArchive closes the archive located at path, and removes it if the
+// build failed with err, or if it cannot be closed. It returns the error of
+// the build, or else the one of the closing.
+func closeArchive(archive output.Writer, path string, err error) error {
+	if closeErr := archive.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("%w: close output: %v", ssg.ErrOutput, closeErr)
 	}
-	return strings.Repeat("../", count)
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
 }
 
-func createTagFile(name string) (page string, file *os.File, err error) {
-	page = path.Join("tags", name+".html")
-	outPath := filepath.Join(flagOutDir, page)
-	if err = os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-		return
-	}
-	file, err = os.Create(outPath)
-	return
+// flagError reports the invalid combination of flags described by msg, as the
+// flag package does for the other usage errors, and returns it.
+func flagError(flags *flag.FlagSet, msg string) error {
+	err := errors.New(msg)
+	fmt.Fprintln(flags.Output(), err)
+	return usageError{err}
 }
 
-func renderTag(tag *lintian.Tag, params *tmplParams, tagTmpl *template.Template, renamedTmpl *template.Template, pages chan<- string, wg *sync.WaitGroup) {
-	defer wg.Done()
-	page, file, err := createTagFile(tag.Name)
+// checkDir returns an error if path is not an existing directory.
+func checkDir(path string) error {
+	info, err := os.Stat(path)
 	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-	pages <- page
-	tagParams := tagTmplParams{
-		tmplParams: *params,
-		Tag:        tag,
+		return err
 	}
-	tagParams.Root = rootRelPath(page)
-	if err := tagTmpl.Execute(file, &tagParams); err != nil {
-		panic(err)
+	if !info.IsDir() {
+		return fmt.Errorf("%s: not a directory", path)
 	}
-	for _, name := range tag.RenamedFrom {
-		page, file, err := createTagFile(name)
-		if err != nil {
-			panic(err)
-		}
-		defer file.Close()
-		pages <- page
-		tagParams.Root = rootRelPath(page)
-		tagParams.PrevName = name
-		if err := renamedTmpl.Execute(file, &tagParams); err != nil {
-			panic(err)
-		}
+	return nil
+}
+
+// usageError is an error of the command line arguments.
+type usageError struct {
+	error
+}
+
+// ExitCode returns the exit code corresponding to the error returned by Run.
+func ExitCode(err error) int {
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	case errors.Is(err, ssg.ErrInput):
+		return ExitInput
+	case errors.Is(err, ssg.ErrOutput):
+		return ExitOutput
+	default:
+		return ExitFailure
 	}
 }
 
-func writeAssets() error {
-	files := []struct {
-		name    string
-		content io.Reader
-	}{
-		{"main.css", bytes.NewReader(mainCSS)},
-		{"openlogo-50.svg", bytes.NewReader(logoSVG)},
-		{"favicon.ico", bytes.NewReader(faviconICO)},
+func printStats(stats *ssg.Stats) error {
+	usage := syscall.Rusage{}
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return fmt.Errorf("get resources usage: %w", err)
 	}
-	for _, f := range files {
-		if err := ioutil.WriteFile(flagOutDir, f.name, f.content); err != nil {
-			return err
-		}
+	fmt.Printf("number of tags: %d\nnumber of pages: %d\n", stats.Tags, stats.Pages)
+	fmt.Printf("files written: %d skipped: %d removed: %d\n", stats.Written, stats.Skipped, stats.Removed)
+	if state := stats.ExplainTags; state != nil {
+		fmt.Printf("tags json generation CPU time: %v (user: %v sys: %v)\n",
+			(state.UserTime() + state.SystemTime()).Round(time.Millisecond),
+			state.UserTime().Round(time.Millisecond),
+			state.SystemTime().Round(time.Millisecond),
+		)
 	}
+	fmt.Printf(`website generation CPU time: %v (user: %v sys: %v)
+total duration: %v
+`,
+		time.Duration(usage.Utime.Nano()+usage.Stime.Nano()).Round(time.Millisecond),
+		time.Duration(usage.Utime.Nano()).Round(time.Millisecond),
+		time.Duration(usage.Stime.Nano()).Round(time.Millisecond),
+		time.Now().Sub(start).Round(time.Millisecond),
+	)
 	return nil
 }
 
-func writeSitemap(baseURL string, pages []string) error {
-	file, err := os.Create(filepath.Join(flagOutDir, "sitemap.txt"))
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	sort.Strings(pages)
-	builder := strings.Builder{}
-	builder.Grow(len(pages) * 32)
-	for _, page := range pages {
-		builder.WriteString(baseURL + page + "\n")
+// Run runs lintian-ssg with the given command line arguments, and returns the
+// error that made it fail, if any. The exit code corresponding to this error
+// is given by ExitCode.
+func Run(args []string) error {
+	var (
+		opts        ssg.Options
+		inputs      stringsFlag
+		outputPath  string
+		serveAddr   string
+		templates   string
+		assets      string
+		help        bool
+		stats       bool
+		showVersion bool
+		watchInputs bool
+	)
+	flags := flag.NewFlagSet("lintian-ssg", flag.ContinueOnError)
+	flags.StringVar(&assets, "assets", "", flagAssetsHelp)
+	flags.BoolVar(&opts.Atomic, "atomic", false, flagAtomicHelp)
+	flags.StringVar(&opts.BaseURL, "base-url", "", flagBaseURLHelp)
+	flags.StringVar(&opts.DebianCSS, "debian-css", "", flagDebianCSSHelp)
+	flags.StringVar(&opts.DevrefURL, "devref-url", goldmark_ext.DefaultDevrefURL, flagDevrefURLHelp)
+	flags.StringVar(&opts.Footer, "footer", "", flagFooterHelp)
+	flags.BoolVar(&help, "h", false, flagHelpHelp)
+	flags.BoolVar(&help, "help", false, flagHelpHelp)
+	flags.Var(&inputs, "input", flagInputHelp)
+	flags.IntVar(&opts.Jobs, "jobs", 0, flagJobsHelp)
+	flags.IntVar(&opts.KeepBuilds, "keep-builds", 0, flagKeepBuildsHelp)
+	flags.BoolVar(&opts.NoSitemap, "no-sitemap", false, flagNoSitemapHelp)
+	flags.StringVar(&opts.OutDir, "o", ssg.DefaultOutDir, flagOutDirHelp)
+	flags.StringVar(&opts.OutDir, "output-dir", ssg.DefaultOutDir, flagOutDirHelp)
+	flags.StringVar(&outputPath, "output", "", flagOutputHelp)
+	flags.StringVar(&opts.PolicyURL, "policy-url", goldmark_ext.DefaultPolicyURL, flagPolicyURLHelp)
+	flags.StringVar(&serveAddr, "serve", "", flagServeHelp)
+	flags.BoolVar(&stats, "stats", false, flagStatsHelp)
+	flags.StringVar(&templates, "templates", "", flagTemplatesHelp)
+	flags.BoolVar(&showVersion, "version", false, flagVersionHelp)
+	flags.BoolVar(&watchInputs, "watch", false, flagWatchHelp)
+	flags.Usage = func() {
+		if help {
+			usage(os.Stdout)
+		} else {
+			usage(flags.Output())
+		}
 	}
-	if _, err := file.WriteString(builder.String()); err != nil {
-		return err
+	if err := flags.Parse(args); err != nil {
+		return usageError{err}
 	}
-	return nil
-}
 
-func writeManual(tmpl *template.Template, params *tmplParams, path string, pages chan<- string) error {
-	file, err := os.Open(getEnv("LINTIAN_MANUAL_PATH", manualPath))
-	if err != nil {
-		return err
+	if help {
+		flags.Usage()
+		return nil
 	}
-	defer file.Close()
-	reader := ioutil.NewBodyFilterReader(file)
-	body := bytes.Buffer{}
-	if _, err := io.Copy(&body, reader); err != nil {
-		return err
+	if showVersion {
+		fmt.Println(version.Number)
+		return nil
 	}
-	pages <- path
-	manualParams := manualTmplParams{*params, template.HTML(body.String())}
-	manualParams.Root = rootRelPath(path)
-	out := bytes.Buffer{}
-	if err := tmpl.Execute(&out, &manualParams); err != nil {
-		return err
+	for _, input := range inputs {
+		if input == "-" && (serveAddr != "" || watchInputs) {
+			return flagError(flags, "--serve and --watch cannot read the tags from stdin")
+		}
 	}
-	return ioutil.WriteFile(flagOutDir, path, &out)
-}
-
-func writeSimplePage(tmpl *template.Template, params any, path string, pages chan<- string) error {
-	file, err := os.Create(filepath.Join(flagOutDir, path))
-	if err != nil {
-		return err
+	if watchInputs && output.IsArchive(outputPath) {
+		return flagError(flags, "--watch cannot update an archive")
 	}
-	defer file.Close()
-	if pages != nil {
-		pages <- path
+	opts.Inputs = inputs
+	opts.ManualPath = getEnv("LINTIAN_MANUAL_PATH", ssg.DefaultManualPath)
+	opts.Log = log.New(os.Stderr, "", 0)
+	watched := []string{opts.ManualPath}
+	if templates != "" {
+		if err := checkDir(templates); err != nil {
+			return fmt.Errorf("%w: templates: %v", ssg.ErrInput, err)
+		}
+		opts.Templates = os.DirFS(templates)
+		watched = append(watched, templates)
 	}
-	return tmpl.Execute(file, params)
-}
-
-func handlePages(pages <-chan string, count *int, wg *sync.WaitGroup) {
-	defer wg.Done()
-	s := make([]string, 0, 2048)
-	for page := range pages {
-		s = append(s, page)
+	if opts.DebianCSS != "" {
+		watched = append(watched, opts.DebianCSS)
 	}
-	if flagBaseURL != "" && !flagNoSitemap {
-		if err := writeSitemap(flagBaseURL, s); err != nil {
-			panic(err)
+	if assets != "" {
+		if err := checkDir(assets); err != nil {
+			return fmt.Errorf("%w: assets: %v", ssg.ErrInput, err)
 		}
+		opts.Assets = os.DirFS(assets)
+		watched = append(watched, assets)
 	}
-	*count = len(s)
-}
-
-func withRoot(params tmplParams, root string) tmplParams {
-	params.Root = root
-	return params
-}
-
-func checkErr(err error, msg ...any) {
-	if err != nil {
-		panic(fmt.Sprintln(append(append([]any{"ERROR:"}, msg...), err)...))
+	for _, input := range inputs {
+		watched = append(watched, input)
 	}
-}
-
-func Run() {
-	log.SetFlags(0)
-	flag.StringVar(&flagBaseURL, "base-url", "", flagBaseURLHelp)
-	flag.StringVar(&flagFooter, "footer", "", flagFooterHelp)
-	flag.BoolVar(&flagHelp, "h", false, flagHelpHelp)
-	flag.BoolVar(&flagHelp, "help", false, flagHelpHelp)
-	flag.BoolVar(&flagNoSitemap, "no-sitemap", false, flagNoSitemapHelp)
-	flag.StringVar(&flagOutDir, "o", flagOutDirDef, flagOutDirHelp)
-	flag.StringVar(&flagOutDir, "output-dir", flagOutDirDef, flagOutDirHelp)
-	flag.BoolVar(&flagStats, "stats", false, flagStatsHelp)
-	flag.BoolVar(&flagVersion, "version", false, flagVersionHelp)
-	flag.Usage = usage
-	flag.Parse()
-
-	if flagHelp {
-		flag.Usage()
-		return
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if serveAddr != "" {
+		return serveSite(ctx, opts, serveAddr, watched)
 	}
-	if flagVersion {
-		fmt.Println(version.Number)
-		return
+	if outputPath != "" {
+		out, err := output.Open(outputPath)
+		if err != nil {
+			return fmt.Errorf("%w: create output: %v", ssg.ErrOutput, err)
+		}
+		if dir, ok := out.(output.Dir); ok {
+			opts.OutDir = string(dir)
+		} else {
+			opts.Output = out
+		}
 	}
-	if flagBaseURL != "" && !strings.HasSuffix(flagBaseURL, "/") {
-		flagBaseURL += "/"
+	generator, err := ssg.New(opts)
+	if err == nil {
+		err = generator.Run(ctx)
 	}
-
-	checkErr(os.MkdirAll(flagOutDir, 0755), "create out dir:")
-
-	pagesChan := make(chan string, 32)
-	pagesWG := sync.WaitGroup{}
-	pagesWG.Add(1)
-	var pagesCount int
-	go handlePages(pagesChan, &pagesCount, &pagesWG)
-
-	indexTmpl := template.Must(template.New("index").Parse(indexTmplStr))
-	tagTmpl := template.Must(template.Must(indexTmpl.Clone()).Parse(tagTmplStr))
-	renamedTmpl := template.Must(template.Must(indexTmpl.Clone()).Parse(renamedTmplStr))
-	manualTmpl := template.Must(template.Must(indexTmpl.Clone()).Parse(manualTmplStr))
-	aboutTmpl := template.Must(template.Must(indexTmpl.Clone()).Parse(aboutTmplStr))
-	e404Tmpl := template.Must(template.Must(indexTmpl.Clone()).Parse(e404TmplStr))
-
-	jsonTagsCmd := exec.Command("lintian-explain-tags", "--format=json")
-	jsonTagsCmd.Stderr = os.Stderr
-	jsonTagsOut, err := jsonTagsCmd.StdoutPipe()
-	checkErr(err)
-	jsonTagsDecoder := json.NewDecoder(jsonTagsOut)
-	checkErr(jsonTagsCmd.Start(), "lintian-explain-tags --format=json:")
-
-	date := time.Now().UTC()
-	params := tmplParams{
-		BaseURL:     flagBaseURL,
-		DateYear:    date.Year(),
-		DateHuman:   date.Format(time.RFC1123),
-		DateMachine: date.Format(time.RFC3339),
-		Version:     version.Number,
-		FooterHTML:  markdown.ToHTML(flagFooter, markdown.StyleInline),
+	if opts.Output != nil {
+		err = closeArchive(opts.Output, outputPath, err)
 	}
-
-	tagList := make([]string, 0, 2048)
-
-	// discard open bracket
-	_, err = jsonTagsDecoder.Token()
-	checkErr(err)
-
-	tagsWG := sync.WaitGroup{}
-	// while the array contains values
-	for jsonTagsDecoder.More() {
-		var tag lintian.Tag
-		checkErr(jsonTagsDecoder.Decode(&tag))
-		if params.VersionLintian == "" {
-			params.VersionLintian = tag.LintianVersion
-		}
-		tagsWG.Add(1)
-		go renderTag(&tag, &params, tagTmpl, renamedTmpl, pagesChan, &tagsWG)
-		tagList = append(tagList, tag.Name)
+	if err != nil {
+		return err
 	}
-
-	// discard closing bracket
-	_, err = jsonTagsDecoder.Token()
-	checkErr(err)
-
-	tagListJSON, err := json.Marshal(tagList)
-	checkErr(err, "marshal tagList:")
-	checkErr(ioutil.WriteFile(flagOutDir, "taglist.json", bytes.NewReader(tagListJSON)), "write taglist:")
-	checkErr(writeAssets(), "write assets:")
-	checkErr(writeManual(manualTmpl, &params, "manual/index.html", pagesChan), "write manual:")
-	indexParams := indexTmplParams{withRoot(params, "./"), tagList}
-	checkErr(writeSimplePage(indexTmpl, indexParams, "index.html", pagesChan), "write index.html:")
-	checkErr(writeSimplePage(aboutTmpl, withRoot(params, "./"), "about.html", pagesChan), "write about.html:")
-	checkErr(writeSimplePage(e404Tmpl, withRoot(params, "/"), "404.html", nil), "write 404.html:")
-
-	tagsWG.Wait()
-	close(pagesChan)
-	if err := jsonTagsCmd.Wait(); err != nil {
-		log.Println("WARNING: lintian-explain-tags --format=json:", err)
+	if stats {
+		if err := printStats(&generator.Stats); err != nil {
+			return err
+		}
 	}
-
-	pagesWG.Wait()
-	if flagStats {
-		usage := syscall.Rusage{}
-		checkErr(syscall.Getrusage(syscall.RUSAGE_SELF, &usage), "get resources usage:")
-		fmt.Printf(`number of tags: %d
-number of pages: %d
-tags json generation CPU time: %v (user: %v sys: %v)
-website generation CPU time: %v (user: %v sys: %v)
-total duration: %v
-`,
-			len(tagList),
-			pagesCount,
-			(jsonTagsCmd.ProcessState.UserTime() + jsonTagsCmd.ProcessState.SystemTime()).Round(time.Millisecond),
-			jsonTagsCmd.ProcessState.UserTime().Round(time.Millisecond),
-			jsonTagsCmd.ProcessState.SystemTime().Round(time.Millisecond),
-			time.Duration(usage.Utime.Nano()+usage.Stime.Nano()).Round(time.Millisecond),
-			time.Duration(usage.Utime.Nano()).Round(time.Millisecond),
-			time.Duration(usage.Stime.Nano()).Round(time.Millisecond),
-			time.Now().Sub(start).Round(time.Millisecond),
-		)
+	if watchInputs {
+		return watchSite(ctx, generator, watched, opts.Log)
 	}
+	return nil
 }
 
 func main() {
-	defer func() {
-		if err := recover(); err != nil {
-			log.Fatal(err)
+	log.SetFlags(0)
+	if err := Run(os.Args[1:]); err != nil {
+		code := ExitCode(err)
+		if code != ExitUsage { // already reported by the flag package
+			log.Println("ERROR:", err)
 		}
-	}()
-	Run()
+		os.Exit(code)
+	}
 }
@@ -4,18 +4,21 @@
 package main_test
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
 	"errors"
-	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	main "github.com/n-peugnet/lintian-ssg"
 	"github.com/n-peugnet/lintian-ssg/lintian"
@@ -43,9 +46,9 @@ var e = regexp.QuoteMeta
 
 // setup creates a temporary directory that contains a "bin" dir with an
 // executable dummy "lintian-explain-tags" command which is then added in
-// front of the PATH, and finally sets the "--output-dir" CLI flag and
-// return it.
-func setup(t *testing.T, lintianExplainTagsOutputs ...any) fs.FS {
+// front of the PATH, and finally returns the output directory along with the
+// CLI arguments setting it.
+func setup(t testing.TB, lintianExplainTagsOutputs ...any) (fs.FS, []string) {
 	checkErr := func(err error) {
 		if err != nil {
 			t.Fatal(err)
@@ -70,13 +73,7 @@ func setup(t *testing.T, lintianExplainTagsOutputs ...any) fs.FS {
 	checkErr(err)
 	t.Setenv("LINTIAN_MANUAL_PATH", manualPath)
 
-	// Reset command line flags
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.PanicOnError)
-	flag.CommandLine.Usage = func() { flag.Usage() }
-
-	// Reset CLI args, and add output dir
 	outDir := filepath.Join(tmpDir, "out")
-	os.Args = []string{os.Args[0], "-o", outDir}
 
 	// Store stdout and stderr
 	prevStdout := os.Stdout
@@ -90,7 +87,7 @@ func setup(t *testing.T, lintianExplainTagsOutputs ...any) fs.FS {
 		os.Stdout = prevStdout
 		os.Stderr = prevStderr
 	})
-	return os.DirFS(outDir)
+	return os.DirFS(outDir), []string{"-o", outDir}
 }
 
 func buildSetupArgs(exitCode int, tags []lintian.Tag) []any {
@@ -104,17 +101,26 @@ func buildSetupArgs(exitCode int, tags []lintian.Tag) []any {
 	return out
 }
 
-func expectPanic(t *testing.T, substr string, fn func()) {
-	defer func() {
-		if err := recover(); err != nil {
-			if !strings.Contains(fmt.Sprint(err), substr) {
-				t.Fatalf("panic does not contain %q: %q", substr, err)
-			}
-		} else {
-			t.Fatal("expected panic")
-		}
-	}()
-	fn()
+// run calls main.Run with args and fails the test if it returns an error.
+func run(t testing.TB, args []string) {
+	if err := main.Run(args); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+// expectError calls main.Run with args and verifies that it returns an error
+// containing substr, corresponding to the given exit code.
+func expectError(t *testing.T, args []string, substr string, code int) {
+	err := main.Run(args)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), substr) {
+		t.Fatalf("error does not contain %q: %q", substr, err)
+	}
+	if actual := main.ExitCode(err); actual != code {
+		t.Fatalf("expected exit code %d, got: %d", code, actual)
+	}
 }
 
 // assertContains verifies for each of the given needles that they are in
@@ -201,11 +207,13 @@ func getHelp(t *testing.T) string {
 }
 
 func TestBasic(t *testing.T) {
-	outDir := setup(t, buildSetupArgs(0, []lintian.Tag{
+	outDir, args := setup(t, buildSetupArgs(0, []lintian.Tag{
 		{
 			Name:           "test-tag",
 			NameSpaced:     false,
 			Visibility:     lintian.LevelInfo,
+			Check:          "test/check-name",
+			ShowAlways:     true,
 			Explanation:    "This is a test.",
 			LintianVersion: lintianVersion,
 			RenamedFrom:    []string{"previous-tag"},
@@ -214,17 +222,44 @@ func TestBasic(t *testing.T) {
 			Name:           "nested/test/tag",
 			NameSpaced:     true,
 			Visibility:     lintian.LevelError,
-			Explanation:    "This is a nested test.",
+			Check:          "test",
+			Explanation:    "This is a nested test, see <code>test-tag</code> and other-test-tag.",
+			LintianVersion: lintianVersion,
+		},
+		{
+			Name:           "other-test-tag",
+			NameSpaced:     false,
+			Visibility:     lintian.LevelWarning,
+			Check:          "test/check-name",
+			Experimental:   true,
+			Explanation:    "This is another test.",
+			SeeAlso:        []string{"test-tag", "other-test-tag"},
 			LintianVersion: lintianVersion,
 		},
 	})...)
-	main.Run()
+	run(t, args)
 
 	assertContains(t, outDir, "index.html",
-		`<a href="./tags/test-tag.html">test-tag</a>`,
-		`<a href="./tags/nested/test/tag.html">nested/test/tag</a>`,
+		`<li class="info"><a href="./tags/test-tag.html">test-tag</a>`,
+		`<li class="error"><a href="./tags/nested/test/tag.html">nested/test/tag</a>`,
+		`<li class="warning experimental"><a href="./tags/other-test-tag.html">other-test-tag</a>`,
+		`<label for="filter-all">all (3)</label>`,
+		`<label for="filter-error"><code class="badge error">error</code> (1)</label>`,
+		`<label for="filter-pedantic"><code class="badge pedantic">pedantic</code> (0)</label>`,
+		`<label for="filter-experimental"><code class="badge experimental">experimental</code> (1)</label>`,
+		`<a href="./severities/warning.html">warning</a>`,
 		`<link rel="stylesheet" href="./main.css">`,
 	)
+	assertContains(t, outDir, "severities/error.html",
+		`<h1>Lintian tags of severity error</h1>`,
+		`<li><code class="badge error">error</code> <a href="../tags/nested/test/tag.html">nested/test/tag</a>`,
+		`<link rel="stylesheet" href="../main.css">`,
+	)
+	assertContains(t, outDir, "severities/pedantic.html", `<p>Number of tags: 0</p>`)
+	assertContains(t, outDir, "experimental.html",
+		`<p>Number of tags: 1</p>`,
+		`<li><code class="badge warning experimental">warning</code> <a href="./tags/other-test-tag.html">other-test-tag</a>`,
+	)
 	assertContains(t, outDir, "manual/index.html",
 		`MANUAL CONTENT`,
 		`<link rel="stylesheet" href="../main.css">`,
@@ -232,29 +267,82 @@ func TestBasic(t *testing.T) {
 	assertContains(t, outDir, "tags/test-tag.html",
 		`<p>This is a test.</p>`,
 		`<link rel="stylesheet" href="../main.css">`,
+		`<td><code>true</code></td>`,
+		`<li><a href="../tags/nested/test/tag.html"><code>nested/test/tag</code></a></li>`,
+		`<a href="../checks/test/check-name.html"><code>test/check-name</code></a> (<a href="https://salsa.debian.org/lintian/lintian/-/blob/1.118.0/lib/Lintian/Check/Test/CheckName.pm">source</a>)`,
 	)
 	assertContains(t, outDir, "tags/previous-tag.html",
 		`<a href="../tags/test-tag.html"><code>test-tag</code></a>`,
 		`<link rel="stylesheet" href="../main.css">`,
 	)
 	assertContains(t, outDir, "tags/nested/test/tag.html",
-		`<p>This is a nested test.</p>`,
+		`<p>This is a nested test, see <code><a href="../../../tags/test-tag.html">test-tag</a></code> and <a href="../../../tags/other-test-tag.html">other-test-tag</a>.</p>`,
 		`<link rel="stylesheet" href="../../../main.css">`,
 	)
-	assertEquals(t, outDir, "taglist.json", `["test-tag","nested/test/tag"]`)
-	assertSame(t, outDir, "main.css", "assets/main.css")
-	assertSame(t, outDir, "favicon.ico", "assets/favicon.ico")
-	assertSame(t, outDir, "openlogo-50.svg", "assets/openlogo-50.svg")
+	assertContains(t, outDir, "tags/other-test-tag.html",
+		`<li><p><a href="../tags/test-tag.html">test-tag</a></p>
+</li>`,
+		`<li><p>other-test-tag</p>
+</li>`,
+	)
+	assertContains(t, outDir, "checks/test/check-name.html",
+		`<h1>Check <code>test/check-name</code></h1>`,
+		`<a href="https://salsa.debian.org/lintian/lintian/-/blob/1.118.0/lib/Lintian/Check/Test/CheckName.pm">source code</a>`,
+		`<li><code class="badge warning experimental">warning</code> <a href="../../tags/other-test-tag.html">other-test-tag</a>
+      <li><code class="badge info">info</code> <a href="../../tags/test-tag.html">test-tag</a>`,
+		`<link rel="stylesheet" href="../../main.css">`,
+	)
+	assertContains(t, outDir, "checks/test.html",
+		`<li><code class="badge error">error</code> <a href="../tags/nested/test/tag.html">nested/test/tag</a>`,
+	)
+	assertContains(t, outDir, "checks/index.html",
+		`<a href="./test.html">test</a> (1)`,
+		`<a href="./test/check-name.html">check-name</a> (2)`,
+		`<link rel="stylesheet" href="../main.css">`,
+	)
+	assertEquals(t, outDir, "taglist.json", `["test-tag","nested/test/tag","other-test-tag"]`)
+	assertContains(t, outDir, "search-index.js",
+		`var lintianSearchIndex = {"docs":["test-tag","nested/test/tag","other-test-tag"],`,
+		`"nested/test/tag":[1,20]`,
+		`"test-tag":[0,20]`,
+		`"stopWords":["a","about",`,
+	)
+	assertContains(t, outDir, "search.html",
+		`<script src="./search-index.js"></script>`,
+		`<link rel="stylesheet" href="./main.css">`,
+	)
+	assertSame(t, outDir, "main.css", "ssg/assets/main.css")
+	assertSame(t, outDir, "favicon.ico", "ssg/assets/favicon.ico")
+	assertSame(t, outDir, "openlogo-50.svg", "ssg/assets/openlogo-50.svg")
 }
 
 func TestJSONTagsError(t *testing.T) {
-	outDir := setup(t, buildSetupArgs(1, []lintian.Tag{})...)
-	main.Run()
+	outDir, args := setup(t, buildSetupArgs(1, []lintian.Tag{})...)
+	run(t, args)
 	assertContains(t, outDir, ".stderr", "WARNING: lintian-explain-tags --format=json: ")
 }
 
+func TestJSONTagsInvalid(t *testing.T) {
+	// The output is larger than the pipe buffer, so that the command stays
+	// blocked writing it if it is not read until the end.
+	output := []byte("[x" + strings.Repeat(" ", 1<<20))
+	_, args := setup(t, 0, output)
+	done := make(chan error)
+	go func() {
+		done <- main.Run(args)
+	}()
+	select {
+	case err := <-done:
+		if !strings.Contains(fmt.Sprint(err), "read tags") || main.ExitCode(err) != main.ExitInput {
+			t.Fatal("expected read tags input error, got:", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("lintian-ssg did not exit")
+	}
+}
+
 func TestBaseURL(t *testing.T) {
-	outDir := setup(t, buildSetupArgs(0, []lintian.Tag{
+	outDir, args := setup(t, buildSetupArgs(0, []lintian.Tag{
 		{
 			Name:           "test-tag",
 			NameSpaced:     false,
@@ -264,8 +352,8 @@ func TestBaseURL(t *testing.T) {
 			RenamedFrom:    []string{"previous-tag"},
 		},
 	})...)
-	os.Args = append(os.Args, "--base-url=https://lintian.club1.fr")
-	main.Run()
+	args = append(args, "--base-url=https://lintian.club1.fr")
+	run(t, args)
 
 	assertContains(t, outDir, "index.html", `<link rel="canonical" href="https://lintian.club1.fr/index.html`)
 	assertContains(t, outDir, "manual/index.html", `<link rel="canonical" href="https://lintian.club1.fr/manual/index.html`)
@@ -281,7 +369,7 @@ func TestBaseURL(t *testing.T) {
 }
 
 func TestNoSitemap(t *testing.T) {
-	outDir := setup(t, buildSetupArgs(0, []lintian.Tag{
+	outDir, args := setup(t, buildSetupArgs(0, []lintian.Tag{
 		{
 			Name:           "test-tag",
 			NameSpaced:     false,
@@ -290,8 +378,8 @@ func TestNoSitemap(t *testing.T) {
 			LintianVersion: lintianVersion,
 		},
 	})...)
-	os.Args = append(os.Args, "--base-url=https://lintian.club1.fr", "--no-sitemap")
-	main.Run()
+	args = append(args, "--base-url=https://lintian.club1.fr", "--no-sitemap")
+	run(t, args)
 
 	_, err := outDir.Open("sitemap.txt")
 	if err == nil {
@@ -303,28 +391,28 @@ func TestNoSitemap(t *testing.T) {
 }
 
 func TestNonExistingFlag(t *testing.T) {
-	outDir := setup(t)
-	os.Args = append(os.Args, "--non-existing-flag")
-	expectPanic(t, "-non-existing-flag", main.Run)
+	outDir, args := setup(t)
+	args = append(args, "--non-existing-flag")
+	expectError(t, args, "-non-existing-flag", main.ExitUsage)
 	assertContains(t, outDir, ".stderr", getHelp(t))
 }
 
 func TestHelp(t *testing.T) {
-	outDir := setup(t)
-	os.Args = append(os.Args, "--help")
-	main.Run()
+	outDir, args := setup(t)
+	args = append(args, "--help")
+	run(t, args)
 	assertEquals(t, outDir, ".stdout", getHelp(t))
 }
 
 func TestVersion(t *testing.T) {
-	outDir := setup(t)
-	os.Args = append(os.Args, "--version")
-	main.Run()
+	outDir, args := setup(t)
+	args = append(args, "--version")
+	run(t, args)
 	assertContains(t, outDir, ".stdout", version.Number)
 }
 
 func TestStats(t *testing.T) {
-	outDir := setup(t, buildSetupArgs(0, []lintian.Tag{
+	outDir, args := setup(t, buildSetupArgs(0, []lintian.Tag{
 		{
 			Name:           "test-tag",
 			NameSpaced:     false,
@@ -333,24 +421,647 @@ func TestStats(t *testing.T) {
 			LintianVersion: lintianVersion,
 		},
 	})...)
-	os.Args = append(os.Args, "--stats")
-	main.Run()
+	args = append(args, "--stats")
+	run(t, args)
 	assertRegexp(t, outDir, ".stdout",
 		e("number of tags: 1"),
-		e("number of pages: 4"),
+		e("number of pages: 14"),
+		`files written: \d+ skipped: 0 removed: 0`,
 		`tags json generation CPU time: (\d.)?\d+m?s \(user: (\d.)?\d+m?s sys: (\d.)?\d+m?s\)`,
 		`website generation CPU time: (\d.)?\d+m?s \(user: (\d.)?\d+m?s sys: (\d.)?\d+m?s\)`,
 		`total duration: (\d.)?\d+m?s`,
 	)
 }
 
+func TestIncremental(t *testing.T) {
+	outDir, args := setup(t)
+	tags := []lintian.Tag{
+		{
+			Name:           "test-tag",
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is a test.",
+			LintianVersion: lintianVersion,
+		},
+		{
+			Name:           "removed-tag",
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This tag will be removed.",
+			LintianVersion: lintianVersion,
+		},
+	}
+	input := writeInput(t, tags)
+	t.Setenv("PATH", "")
+	run(t, append(args, "--input", input))
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(args[1], "tags", "test-tag.html"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	input = writeInput(t, tags[:1])
+	run(t, append(args, "--input", input, "--stats"))
+	info, err := fs.Stat(outDir, "tags/test-tag.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Error("expected unchanged page not to be rewritten")
+	}
+	if _, err := fs.Stat(outDir, "tags/removed-tag.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected stale page to be removed, got:", err)
+	}
+	assertRegexp(t, outDir, ".stdout", `files written: [1-9]\d* skipped: [1-9]\d* removed: 1\n`)
+}
+
+func TestAtomic(t *testing.T) {
+	_, args := setup(t)
+	input := writeInput(t, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is a test.",
+			LintianVersion: lintianVersion,
+		},
+	})
+	t.Setenv("PATH", "")
+	siteDir := filepath.Join(t.TempDir(), "site")
+	args = append(args, "-o", siteDir, "--input", input, "--atomic", "--keep-builds", "1")
+	for i := 0; i < 3; i++ {
+		run(t, args)
+	}
+
+	info, err := os.Lstat(siteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("expected output dir to be a symlink")
+	}
+	outDir := os.DirFS(siteDir)
+	assertContains(t, outDir, "tags/test-tag.html", `<p>This is a test.</p>`)
+	builds, err := os.ReadDir(siteDir + ".builds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 2 {
+		t.Fatalf("expected 2 builds, got: %d", len(builds))
+	}
+	prev, err := os.Stat(filepath.Join(siteDir+".builds", builds[0].Name(), "tags", "test-tag.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := os.Stat(filepath.Join(siteDir, "tags", "test-tag.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(prev, current) {
+		t.Error("expected unchanged page to be hard linked from the previous build")
+	}
+}
+
+func TestAtomicExistingDir(t *testing.T) {
+	_, args := setup(t)
+	input := writeInput(t, []lintian.Tag{{Name: "test-tag", LintianVersion: lintianVersion}})
+	t.Setenv("PATH", "")
+	siteDir := filepath.Join(t.TempDir(), "site")
+	args = append(args, "-o", siteDir, "--input", input)
+	run(t, args)
+	run(t, append(args, "--atomic", "--keep-builds", "1"))
+
+	info, err := os.Lstat(siteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("expected output dir to be a symlink")
+	}
+	if _, err := os.Lstat(siteDir + ".tmp"); !os.IsNotExist(err) {
+		t.Error("expected temporary symlink to be removed, got:", err)
+	}
+	builds, err := os.ReadDir(siteDir + ".builds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 2 {
+		t.Fatalf("expected the previous output dir to be kept as a build, got %d builds", len(builds))
+	}
+	assertContains(t, os.DirFS(filepath.Join(siteDir+".builds", builds[0].Name())), "tags/test-tag.html", "test-tag")
+}
+
+func TestServeStdin(t *testing.T) {
+	_, args := setup(t)
+	expectError(t, append(args, "--serve", "localhost:0", "--input", "-"), "stdin", main.ExitUsage)
+}
+
+func TestTemplates(t *testing.T) {
+	outDir, args := setup(t)
+	input := writeInput(t, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is a test.",
+			LintianVersion: lintianVersion,
+		},
+	})
+	templates := t.TempDir()
+	files := map[string]string{
+		"head-extra.html.tmpl": `<meta name="custom">`,
+		"tag.html.tmpl":        `{{ define "content" }}<p>CUSTOM TAG {{ .Name }}</p>{{ end }}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(templates, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", "")
+	run(t, append(args, "--input", input, "--templates", templates))
+	assertContains(t, outDir, "tags/test-tag.html", `<meta name="custom">`, `<p>CUSTOM TAG test-tag</p>`)
+	assertContains(t, outDir, "index.html", `<meta name="custom">`)
+
+	expectError(t, append(args, "--input", input, "--templates", filepath.Join(templates, "tag.html.tmpl")), "not a directory", main.ExitInput)
+}
+
+func TestTemplatesMissingField(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+	}{
+		{"check", `{{ define "content" }}{{ .Missing }}{{ end }}`},
+		// The sample data used to check the templates has no explanation.
+		{"build", `{{ define "content" }}{{ if .Explanation }}{{ .Missing }}{{ end }}{{ end }}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, args := setup(t)
+			input := writeInput(t, []lintian.Tag{
+				{
+					Name:           "test-tag",
+					Visibility:     lintian.LevelInfo,
+					Explanation:    "This is a test.",
+					LintianVersion: lintianVersion,
+				},
+			})
+			templates := t.TempDir()
+			if err := os.WriteFile(filepath.Join(templates, "tag.html.tmpl"), []byte(c.template), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", "")
+			expectError(t, append(args, "--input", input, "--templates", templates), "Missing", main.ExitInput)
+		})
+	}
+}
+
+func TestAssets(t *testing.T) {
+	outDir, args := setup(t)
+	input := writeInput(t, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is a test.",
+			LintianVersion: lintianVersion,
+		},
+	})
+	assets := t.TempDir()
+	files := map[string]string{
+		"main.css":                 "body { color: red; }",
+		"robots.txt":               "User-agent: *",
+		"verify/google1234.html":   "google-site-verification: google1234.html",
+		".well-known/security.txt": "Contact: mailto:security@example.org",
+	}
+	for name, content := range files {
+		path := filepath.Join(assets, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", "")
+	run(t, append(args, "--input", input, "--assets", assets, "--base-url", "https://lintian.example.org"))
+	for name, content := range files {
+		assertContains(t, outDir, name, content)
+	}
+	assertSame(t, outDir, "openlogo-50.svg", "ssg/assets/openlogo-50.svg")
+	assertContains(t, outDir, "sitemap.txt", "https://lintian.example.org/verify/google1234.html\n")
+}
+
+// stylesheetRegexp matches the URL of the stylesheets linked by a page.
+var stylesheetRegexp = regexp.MustCompile(`<link rel="stylesheet" href="([^"]*)">`)
+
+func TestDebianCSS(t *testing.T) {
+	customCSS := filepath.Join(t.TempDir(), "debian.css")
+	if err := os.WriteFile(customCSS, []byte("body { color: #C70036; }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir, args := setup(t)
+	oldInput := writeInput(t, []lintian.Tag{{Name: "test-tag", LintianVersion: "2.116.3"}})
+	newInput := writeInput(t, []lintian.Tag{{Name: "test-tag", LintianVersion: "2.118.0"}})
+	t.Setenv("PATH", "")
+	run(t, append(args, "--input", oldInput, "--input", newInput, "--debian-css", customCSS))
+
+	for _, dir := range []string{"2.116.3", "2.118.0", "latest"} {
+		assertSame(t, outDir, dir+"/debian.css", customCSS)
+	}
+	assertContains(t, outDir, "latest/tags/test-tag.html", `<link rel="stylesheet" href="../debian.css">`)
+	count := 0
+	err := fs.WalkDir(outDir, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".html" {
+			return err
+		}
+		content, err := fs.ReadFile(outDir, path)
+		if err != nil {
+			return err
+		}
+		for _, match := range stylesheetRegexp.FindAllSubmatch(content, -1) {
+			count++
+			if url := string(match[1]); strings.Contains(url, "//") {
+				t.Errorf("%s: external stylesheet %s", path, url)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Error("no stylesheet found")
+	}
+}
+
+func TestDebianCSSMissing(t *testing.T) {
+	_, args := setup(t)
+	input := writeInput(t, []lintian.Tag{{Name: "test-tag", LintianVersion: lintianVersion}})
+	missing := filepath.Join(t.TempDir(), "debian.css")
+	expectError(t, append(args, "--input", input, "--debian-css", missing), "debian.css", main.ExitInput)
+}
+
+func TestWatchArchive(t *testing.T) {
+	_, args := setup(t)
+	archivePath := filepath.Join(t.TempDir(), "site.tar.gz")
+	expectError(t, append(args, "--watch", "--output", archivePath), "archive", main.ExitUsage)
+	if _, err := os.Stat(archivePath); !os.IsNotExist(err) {
+		t.Errorf("expected the archive not to be created, got: %v", err)
+	}
+
+	if err := os.WriteFile(archivePath, []byte("EXISTING ARCHIVE"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, append(args, "--watch", "--output", archivePath), "archive", main.ExitUsage)
+	content, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "EXISTING ARCHIVE" {
+		t.Errorf("expected the existing archive to be left untouched, got: %q", content)
+	}
+}
+
+func TestOutputArchive(t *testing.T) {
+	_, args := setup(t)
+	input := writeInput(t, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is a test.",
+			LintianVersion: lintianVersion,
+		},
+	})
+	t.Setenv("PATH", "")
+	archivePath := filepath.Join(t.TempDir(), "site.zip")
+	run(t, append(args, "--input", input, "--output", archivePath))
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	assertContains(t, archive, "tags/test-tag.html", `<p>This is a test.</p>`)
+	assertSame(t, archive, "main.css", "ssg/assets/main.css")
+}
+
+func TestOutputArchiveError(t *testing.T) {
+	_, args := setup(t)
+	input := writeInput(t, []lintian.Tag{{Name: "test-tag", LintianVersion: lintianVersion}})
+	t.Setenv("LINTIAN_MANUAL_PATH", "/non/existing/manual.html")
+	archivePath := filepath.Join(t.TempDir(), "site.tar")
+	expectError(t, append(args, "--input", input, "--output", archivePath), "read manual", main.ExitInput)
+	if _, err := os.Stat(archivePath); !os.IsNotExist(err) {
+		t.Errorf("expected the partial archive to be removed, got: %v", err)
+	}
+}
+
 func TestEmptyPATH(t *testing.T) {
-	setup(t)
+	_, args := setup(t)
 	t.Setenv("PATH", "")
-	expectPanic(t, `ERROR: lintian-explain-tags --format=json: exec: "lintian-explain-tags"`, main.Run)
+	expectError(t, args, `lintian-explain-tags --format=json: exec: "lintian-explain-tags"`, main.ExitInput)
+}
+
+func TestManualNotFound(t *testing.T) {
+	_, args := setup(t, buildSetupArgs(0, []lintian.Tag{})...)
+	t.Setenv("LINTIAN_MANUAL_PATH", "/non/existing/manual.html")
+	expectError(t, args, "write manual: read manual: open /non/existing/manual.html", main.ExitInput)
+}
+
+func TestOutputError(t *testing.T) {
+	_, args := setup(t, buildSetupArgs(0, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			Visibility:     lintian.LevelInfo,
+			LintianVersion: lintianVersion,
+		},
+	})...)
+	outDir := args[1]
+	if err := os.WriteFile(filepath.Join(outDir, "tags"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, args, "write tag test-tag: mkdir "+filepath.Join(outDir, "tags"), main.ExitOutput)
 }
 
 func TestEmptyTagList(t *testing.T) {
-	setup(t, 0, "[]")
-	main.Run()
+	_, args := setup(t, 0, "[]")
+	run(t, args)
+}
+
+func TestInputFile(t *testing.T) {
+	outDir, args := setup(t)
+	tags := buildSetupArgs(0, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			NameSpaced:     false,
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is a test.",
+			LintianVersion: lintianVersion,
+		},
+	})[1].([]byte)
+	inputPath := filepath.Join(t.TempDir(), "tags.json")
+	if err := os.WriteFile(inputPath, tags, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", "")
+	args = append(args, "--input", inputPath)
+	run(t, args)
+	assertContains(t, outDir, "tags/test-tag.html", `<p>This is a test.</p>`)
+	assertEquals(t, outDir, "taglist.json", `["test-tag"]`)
+}
+
+func TestInputStdin(t *testing.T) {
+	outDir, args := setup(t)
+	tags := buildSetupArgs(0, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			NameSpaced:     false,
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is a test.",
+			LintianVersion: lintianVersion,
+		},
+	})[1].([]byte)
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Write(tags); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	prevStdin := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() { os.Stdin = prevStdin })
+	t.Setenv("PATH", "")
+	args = append(args, "--input=-")
+	run(t, args)
+	assertContains(t, outDir, "tags/test-tag.html", `<p>This is a test.</p>`)
+}
+
+func TestInputNotFound(t *testing.T) {
+	_, args := setup(t)
+	args = append(args, "--input", "/non/existing/file.json")
+	expectError(t, args, `open input: stat /non/existing/file.json`, main.ExitInput)
+}
+
+func TestInputSourceTree(t *testing.T) {
+	outDir, args := setup(t)
+	t.Setenv("PATH", "")
+	args = append(args, "--input", filepath.Join("lintian", "testdata", "lintian"))
+	run(t, args)
+	assertContains(t, outDir, "tags/executable-in-usr-lib.html",
+		`<p>The package ships an executable file in /usr/lib.</p>`,
+		`(lintian v2.118.0)`,
+	)
+	assertContains(t, outDir, "tags/old-test-tag.html",
+		`<a href="../tags/teams/js/test-tag.html"><code>teams/js/test-tag</code></a>`,
+	)
+	assertEquals(t, outDir, "taglist.json", `["executable-in-usr-lib","teams/js/test-tag"]`)
+}
+
+func TestReferences(t *testing.T) {
+	outDir, args := setup(t, buildSetupArgs(0, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			NameSpaced:     false,
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "See Debian Policy section 9.1.1 and Developer's Reference 6.2.",
+			SeeAlso:        []string{"§ 4.9", "[Bug#954149](https://bugs.debian.org/954149)"},
+			LintianVersion: lintianVersion,
+		},
+		{
+			Name:           "other-tag",
+			NameSpaced:     false,
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "See lintian(1), Bug#12345 and Bug#954149.",
+			LintianVersion: lintianVersion,
+		},
+	})...)
+	args = append(args, "--policy-url=http://localhost/policy", "--devref-url=http://localhost/devref/")
+	run(t, args)
+	assertContains(t, outDir, "tags/test-tag.html",
+		`<a href="http://localhost/policy/ch-opersys.html#s9.1.1">Debian Policy section 9.1.1</a>`,
+		`<a href="http://localhost/devref/best-pkging-practices.html">Developer's Reference 6.2</a>`,
+		`<a href="http://localhost/policy/ch-source.html#s4.9">§ 4.9</a>`,
+	)
+	assertContains(t, outDir, "policy/index.html",
+		`<h2>Debian Policy Manual</h2>
+    <dl class="references">
+      <dt><a href="http://localhost/policy/ch-source.html#s4.9">§ 4.9</a></dt>
+      <dd>
+        <a href="../tags/test-tag.html">test-tag</a>
+      </dd>
+      <dt><a href="http://localhost/policy/ch-opersys.html#s9.1.1">§ 9.1.1</a></dt>`,
+		`<h2>Debian Developer&#39;s Reference</h2>
+    <dl class="references">
+      <dt><a href="http://localhost/devref/best-pkging-practices.html">§ 6.2</a></dt>`,
+	)
+	assertContains(t, outDir, "references/manpages.html",
+		`<dt><a href="https://manpages.debian.org/lintian%281%29">lintian(1)</a></dt>
+      <dd>
+        <a href="../tags/other-tag.html">other-tag</a>
+      </dd>`,
+	)
+	assertContains(t, outDir, "references/bugs.html",
+		`<dt><a href="https://bugs.debian.org/12345">Bug#12345</a></dt>
+      <dd>
+        <a href="../tags/other-tag.html">other-tag</a>
+      </dd>
+      <dt><a href="https://bugs.debian.org/954149">Bug#954149</a></dt>
+      <dd>
+        <a href="../tags/other-tag.html">other-tag</a>,
+        <a href="../tags/test-tag.html">test-tag</a>
+      </dd>`,
+	)
+}
+
+// writeInput writes the given tags in a JSON file of a temporary directory and
+// returns its path.
+func writeInput(t testing.TB, tags []lintian.Tag) string {
+	content := buildSetupArgs(0, tags)[1].([]byte)
+	inputPath := filepath.Join(t.TempDir(), "tags.json")
+	if err := os.WriteFile(inputPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return inputPath
+}
+
+func TestMultipleVersions(t *testing.T) {
+	outDir, args := setup(t)
+	oldInput := writeInput(t, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is an old test.",
+			LintianVersion: "2.116.3",
+		},
+		{
+			Name:           "removed-tag",
+			Visibility:     lintian.LevelWarning,
+			LintianVersion: "2.116.3",
+		},
+	})
+	newInput := writeInput(t, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			Visibility:     lintian.LevelInfo,
+			Explanation:    "This is a new test.",
+			LintianVersion: "2.118.0",
+		},
+		{
+			Name:           "added-tag",
+			Visibility:     lintian.LevelError,
+			LintianVersion: "2.118.0",
+		},
+	})
+	newDate := time.Date(2024, time.July, 20, 10, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(newInput, newDate, newDate); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", "")
+	args = append(args, "--base-url", "https://lintian.example.org", "--input", newInput, "--input", oldInput)
+	run(t, args)
+	assertContains(t, outDir, "2.116.3/tags/test-tag.html",
+		`<p>This is an old test.</p>`,
+		`<link rel="canonical" href="https://lintian.example.org/2.116.3/tags/test-tag.html">`,
+		`<option value="latest">latest</option>`,
+		`<option value="2.118.0">2.118.0</option>`,
+		`<option value="2.116.3" selected>2.116.3</option>`,
+	)
+	assertContains(t, outDir, "2.118.0/tags/test-tag.html", `<p>This is a new test.</p>`)
+	assertContains(t, outDir, "latest/tags/test-tag.html",
+		`<p>This is a new test.</p>`,
+		`<option value="latest" selected>latest</option>`,
+	)
+	assertContains(t, outDir, "2.116.3/tags/added-tag.html",
+		`This tag does not exist in lintian 2.116.3.`,
+		`<li><a href="../../2.118.0/tags/added-tag.html">lintian 2.118.0</a></li>`,
+	)
+	assertContains(t, outDir, "latest/tags/removed-tag.html",
+		`This tag does not exist in lintian 2.118.0.`,
+		`<li><a href="../../2.116.3/tags/removed-tag.html">lintian 2.116.3</a></li>`,
+	)
+	assertContains(t, outDir, "latest/index.html",
+		`<a href="./changes.html">changes since lintian 2.116.3</a>`,
+		`<link rel="alternate" type="application/atom+xml" title="Lintian tags changes" href="https://lintian.example.org/feed.xml">`,
+	)
+	assertContains(t, outDir, "feed.xml",
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<id>https://lintian.example.org/feed.xml</id>`,
+		`<updated>2024-07-20T10:00:00Z</updated>`,
+		`<entry>
+    <title>added-tag added in lintian 2.118.0</title>
+    <id>https://lintian.example.org/2.118.0/tags/added-tag.html</id>
+    <updated>2024-07-20T10:00:00Z</updated>
+    <link href="https://lintian.example.org/2.118.0/tags/added-tag.html"></link>
+    <summary>New tag.</summary>
+  </entry>`,
+		`<title>test-tag changed in lintian 2.118.0</title>`,
+		`<summary>Explanation changed.</summary>`,
+	)
+	assertContains(t, outDir, "2.118.0/changes.html",
+		`<li><a href="./tags/added-tag.html">added-tag</a></li>`,
+		`<li><a href="./../2.116.3/tags/removed-tag.html">removed-tag</a></li>`,
+		`<div class="diff">This is <del>an old</del><ins>a new</ins> test.</div>`,
+	)
+	assertEquals(t, outDir, "2.118.0/changes.json", `{"from":"2.116.3","to":"2.118.0",`+
+		`"added":["added-tag"],"removed":["removed-tag"],"renamed":[],"severity":[],"experimental":[],`+
+		`"explanation":[{"tag":"test-tag","from":"This is an old test.","to":"This is a new test."}]}`)
+	if _, err := fs.Stat(outDir, "2.116.3/changes.html"); err == nil {
+		t.Error("expected no changes page for the oldest version")
+	}
+	assertContains(t, outDir, "index.html", `<meta http-equiv="refresh" content="0; url=latest/index.html" />`)
+	assertContains(t, outDir, "404.html", `<link rel="stylesheet" href="/latest/main.css">`)
+	assertContains(t, outDir, "sitemap.txt",
+		"https://lintian.example.org/2.116.3/tags/removed-tag.html\n",
+		"https://lintian.example.org/latest/tags/added-tag.html\n",
+	)
+}
+
+func TestMultipleVersionsDuplicate(t *testing.T) {
+	_, args := setup(t)
+	input := writeInput(t, []lintian.Tag{
+		{
+			Name:           "test-tag",
+			LintianVersion: lintianVersion,
+		},
+	})
+	t.Setenv("PATH", "")
+	args = append(args, "--input", input, "--input", input)
+	expectError(t, args, "multiple inputs: "+input+": duplicate lintian version "+lintianVersion, main.ExitInput)
+}
+
+// BenchmarkJobs compares the throughput of the rendering of the tags with a
+// bounded pool of workers to the one of a goroutine per tag.
+func BenchmarkJobs(b *testing.B) {
+	tags := make([]lintian.Tag, 2000)
+	for i := range tags {
+		tags[i] = lintian.Tag{
+			Name:           fmt.Sprintf("test-tag-%d", i),
+			Visibility:     lintian.LevelInfo,
+			Explanation:    fmt.Sprintf("This is test number %d, see test-tag-%d.", i, (i+1)%len(tags)),
+			LintianVersion: lintianVersion,
+		}
+	}
+	cases := []struct {
+		name string
+		jobs int
+	}{
+		{"goroutine-per-tag", -1},
+		{"single-worker", 1},
+		{"worker-per-cpu", runtime.NumCPU()},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			_, args := setup(b)
+			input := writeInput(b, tags)
+			b.Setenv("PATH", "")
+			args = append(args, "--input", input, "--jobs", fmt.Sprint(c.jobs))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				// Remove the manifest, so that all the files are rewritten.
+				if err := os.RemoveAll(filepath.Join(args[1], ".lintian-ssg-manifest.json")); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				run(b, args)
+			}
+		})
+	}
 }
@@ -21,7 +21,7 @@ var bugLinkRegexp = regexp.MustCompile(`^Bug#(\d+)\b`)
 type bugLinkParser struct{}
 
 // NewBugLinkParser returns a new InlineParser that parses bug links
-// in the form Bug#nnnnn .
+// in the form Bug#nnnnn . The bugs are recorded as references of kind RefBug.
 func NewBugLinkParser() parser.InlineParser {
 	return &bugLinkParser{}
 }
@@ -32,9 +32,6 @@ func (p *bugLinkParser) Trigger() []byte {
 }
 
 func (p *bugLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
-	if pc.IsInLinkLabel() {
-		return nil
-	}
 	line, segment := block.PeekLine()
 	consumes := 0
 	start := segment.Start
@@ -53,9 +50,13 @@ func (p *bugLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Cont
 	stop := loc[3]
 	match := string(line[loc[2]:stop])
 	number, _ := strconv.Atoi(match)
-	text := ast.NewTextSegment(text.NewSegment(start, start+stop))
 	buf := make([]byte, 0, len(bugURLFmt)+10)
 	url := fmt.Appendf(buf, bugURLFmt, number)
+	addReference(pc, Reference{RefBug, strconv.Itoa(number), string(url)})
+	if pc.IsInLinkLabel() {
+		return nil
+	}
+	text := ast.NewTextSegment(text.NewSegment(start, start+stop))
 	node := ast.NewLink()
 	node.Destination = url
 	node.AppendChild(node, text)
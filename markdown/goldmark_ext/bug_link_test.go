@@ -5,6 +5,8 @@ package goldmark_ext_test
 
 import (
 	"fmt"
+	"io"
+	"reflect"
 	"testing"
 
 	"github.com/n-peugnet/lintian-ssg/markdown/goldmark_ext"
@@ -59,3 +61,25 @@ func TestBugLink(t *testing.T) {
 		})
 	}
 }
+
+func TestBugLinkReferences(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithParserOptions(parser.WithInlineParsers(
+			util.Prioritized(goldmark_ext.NewBugLinkParser(), 500),
+		)),
+	)
+	src := "See Bug#12345 and [Bug#954149](https://bugs.debian.org/954149)."
+	expected := goldmark_ext.References{
+		{Kind: goldmark_ext.RefBug, ID: "12345", URL: "https://bugs.debian.org/12345"},
+		{Kind: goldmark_ext.RefBug, ID: "954149", URL: "https://bugs.debian.org/954149"},
+	}
+	var actual goldmark_ext.References
+	pc := parser.NewContext()
+	goldmark_ext.SetReferences(pc, &actual)
+	if err := markdown.Convert([]byte(src), io.Discard, parser.WithContext(pc)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("\nexpected: %v\nactual  : %v", expected, actual)
+	}
+}
@@ -20,7 +20,8 @@ var manpageLinkRegexp = regexp.MustCompile(`^[-\w\.]+\([1-9]\)`)
 type manpageLinkParser struct{}
 
 // NewManpageLinkParser returns a new InlineParser that parses manpage links
-// in the form pagename(n).
+// in the form pagename(n). The manpages are recorded as references of kind
+// RefManpage.
 func NewManpageLinkParser() parser.InlineParser {
 	return &manpageLinkParser{}
 }
@@ -31,9 +32,6 @@ func (p *manpageLinkParser) Trigger() []byte {
 }
 
 func (p *manpageLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
-	if pc.IsInLinkLabel() {
-		return nil
-	}
 	line, segment := block.PeekLine()
 	consumes := 0
 	start := segment.Start
@@ -50,9 +48,13 @@ func (p *manpageLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.
 	// Create new node
 	stop := loc[1]
 	match := string(line[loc[0]:stop])
-	text := ast.NewTextSegment(text.NewSegment(start, start+stop))
 	buf := make([]byte, 0, len(manpageURLFmt)+10)
 	url := fmt.Appendf(buf, manpageURLFmt, match)
+	addReference(pc, Reference{RefManpage, match, string(url)})
+	if pc.IsInLinkLabel() {
+		return nil
+	}
+	text := ast.NewTextSegment(text.NewSegment(start, start+stop))
 	node := ast.NewLink()
 	node.Destination = url
 	node.AppendChild(node, text)
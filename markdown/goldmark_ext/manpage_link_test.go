@@ -5,6 +5,8 @@ package goldmark_ext_test
 
 import (
 	"fmt"
+	"io"
+	"reflect"
 	"testing"
 
 	"github.com/n-peugnet/lintian-ssg/markdown/goldmark_ext"
@@ -63,3 +65,25 @@ func TestManpageLink(t *testing.T) {
 		})
 	}
 }
+
+func TestManpageLinkReferences(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithParserOptions(parser.WithInlineParsers(
+			util.Prioritized(goldmark_ext.NewManpageLinkParser(), 500),
+		)),
+	)
+	src := "See lintian(1) and [manual page dh(1)](http://another.url)."
+	expected := goldmark_ext.References{
+		{Kind: goldmark_ext.RefManpage, ID: "lintian(1)", URL: "https://manpages.debian.org/lintian(1)"},
+		{Kind: goldmark_ext.RefManpage, ID: "dh(1)", URL: "https://manpages.debian.org/dh(1)"},
+	}
+	var actual goldmark_ext.References
+	pc := parser.NewContext()
+	goldmark_ext.SetReferences(pc, &actual)
+	if err := markdown.Convert([]byte(src), io.Discard, parser.WithContext(pc)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("\nexpected: %v\nactual  : %v", expected, actual)
+	}
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package goldmark_ext
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Default base URLs of the Debian documents referenced by the policy links.
+const (
+	DefaultPolicyURL = "https://www.debian.org/doc/debian-policy/"
+	DefaultDevrefURL = "https://www.debian.org/doc/manuals/developers-reference/"
+)
+
+// policyChapters maps the chapter numbers of the Debian Policy Manual to
+// their page.
+var policyChapters = map[string]string{
+	"1":  "ch-scope",
+	"2":  "ch-archive",
+	"3":  "ch-binary",
+	"4":  "ch-source",
+	"5":  "ch-controlfields",
+	"6":  "ch-maintainerscripts",
+	"7":  "ch-relationships",
+	"8":  "ch-sharedlibs",
+	"9":  "ch-opersys",
+	"10": "ch-files",
+	"11": "ch-customized-programs",
+	"12": "ch-docs",
+}
+
+// devrefChapters maps the chapter numbers of the Debian Developer's Reference
+// to their page.
+var devrefChapters = map[string]string{
+	"1": "scope",
+	"2": "new-maintainer",
+	"3": "developer-duties",
+	"4": "resources",
+	"5": "pkgs",
+	"6": "best-pkging-practices",
+	"7": "beyond-pkging",
+	"8": "l10n",
+}
+
+// policyLinkPattern is a pattern of policy link. The text of the link is
+// the part of the match that ends with the section number.
+type policyLinkPattern struct {
+	regexp *regexp.Regexp
+	kind   ReferenceKind
+}
+
+const sectionPattern = `(\d+(?:\.\d+)*)\b`
+
+var policyLinkPatterns = []policyLinkPattern{
+	{regexp.MustCompile(`^(?:Debian )?[Pp]olicy(?: [Mm]anual)?,? (?:[Ss]ection|[Cc]hapter|§) ?` + sectionPattern), RefPolicy},
+	{regexp.MustCompile(`^§ ?` + sectionPattern), RefPolicy},
+	{regexp.MustCompile(`^debian-policy ` + sectionPattern), RefPolicy},
+	{regexp.MustCompile(`^Section ` + sectionPattern + `\) in the Debian Policy Manual`), RefPolicy},
+	{regexp.MustCompile(`^(?:Debian )?Developers?['’]?s?['’]? [Rr]eference,? (?:(?:[Ss]ection|[Cc]hapter|§) ?)?` + sectionPattern), RefDevref},
+	{regexp.MustCompile(`^developer-reference ` + sectionPattern), RefDevref},
+	{regexp.MustCompile(`^Section ` + sectionPattern + `\) in the Debian Developer's Reference`), RefDevref},
+}
+
+type policyLinkParser struct {
+	policyURL string
+	devrefURL string
+}
+
+// NewPolicyLinkParser returns a new InlineParser that parses references to
+// the sections of the Debian Policy Manual and Developer's Reference, in the
+// forms "Debian Policy section 9.1.1", "§ 4.9" or "Developer's Reference 6.2",
+// and links them to the documents located at the given base URLs.
+// The sections are recorded as references of kind RefPolicy or RefDevref.
+func NewPolicyLinkParser(policyURL string, devrefURL string) parser.InlineParser {
+	return &policyLinkParser{policyURL, devrefURL}
+}
+
+func (p *policyLinkParser) Trigger() []byte {
+	// ' ' indicates any white spaces and a line head
+	return []byte{' ', '('}
+}
+
+// sectionURL returns the URL of the given section of the document of the
+// given kind, or an empty string if the chapter is unknown.
+func (p *policyLinkParser) sectionURL(kind ReferenceKind, section string) string {
+	chapter, _, _ := strings.Cut(section, ".")
+	switch kind {
+	case RefPolicy:
+		page, ok := policyChapters[chapter]
+		if !ok {
+			return ""
+		}
+		if chapter == section {
+			return p.policyURL + page + ".html"
+		}
+		return p.policyURL + page + ".html#s" + section
+	case RefDevref:
+		page, ok := devrefChapters[chapter]
+		if !ok {
+			return ""
+		}
+		// The sections of the Developer's Reference are anchored by labels
+		// rather than by numbers, so only their chapter can be linked.
+		return p.devrefURL + page + ".html"
+	}
+	return ""
+}
+
+func (p *policyLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	consumes := 0
+	start := segment.Start
+	switch line[0] {
+	case ' ', '(':
+		line = line[1:]
+		consumes++
+		start++
+	}
+	for _, pattern := range policyLinkPatterns {
+		loc := pattern.regexp.FindSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		stop := loc[3]
+		section := string(line[loc[2]:stop])
+		url := p.sectionURL(pattern.kind, section)
+		if url == "" {
+			return nil
+		}
+		addReference(pc, Reference{pattern.kind, section, url})
+		if pc.IsInLinkLabel() {
+			return nil
+		}
+
+		// Create new node
+		text := ast.NewTextSegment(text.NewSegment(start, start+stop))
+		node := ast.NewLink()
+		node.Destination = []byte(url)
+		node.AppendChild(node, text)
+
+		// Adjust parser state
+		block.Advance(stop + consumes)
+		if consumes != 0 {
+			s := segment.WithStop(segment.Start + consumes)
+			ast.MergeOrAppendTextSegment(parent, s)
+		}
+		return node
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package goldmark_ext_test
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/markdown/goldmark_ext"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/testutil"
+	"github.com/yuin/goldmark/util"
+)
+
+func TestPolicyLink(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+		goldmark.WithParserOptions(parser.WithInlineParsers(
+			util.Prioritized(goldmark_ext.NewPolicyLinkParser(
+				goldmark_ext.DefaultPolicyURL,
+				"http://localhost/devref/",
+			), 500),
+		)),
+	)
+	cases := []struct {
+		src      string
+		expected string
+	}{
+		{ // basic case
+			`see Debian Policy section 9.1.1.`,
+			`<p>see <a href="https://www.debian.org/doc/debian-policy/ch-opersys.html#s9.1.1">Debian Policy section 9.1.1</a>.</p>`,
+		},
+		{ // section sign
+			`as required by § 4.9.`,
+			`<p>as required by <a href="https://www.debian.org/doc/debian-policy/ch-source.html#s4.9">§ 4.9</a>.</p>`,
+		},
+		{ // policy manual with section sign in parenthesis
+			`(policy manual §10.4)`,
+			`<p>(<a href="https://www.debian.org/doc/debian-policy/ch-files.html#s10.4">policy manual §10.4</a>)</p>`,
+		},
+		{ // whole chapter
+			`Debian Policy chapter 12`,
+			`<p><a href="https://www.debian.org/doc/debian-policy/ch-docs.html">Debian Policy chapter 12</a></p>`,
+		},
+		{ // lintian see also
+			`debian-policy 9.1.1`,
+			`<p><a href="https://www.debian.org/doc/debian-policy/ch-opersys.html#s9.1.1">debian-policy 9.1.1</a></p>`,
+		},
+		{ // lintian-explain-tags see also
+			`[File System Structure](https://another.url) (Section 9.1.1) in the Debian Policy Manual`,
+			`<p><a href="https://another.url">File System Structure</a> (<a href="https://www.debian.org/doc/debian-policy/ch-opersys.html#s9.1.1">Section 9.1.1</a>) in the Debian Policy Manual</p>`,
+		},
+		{ // developer's reference
+			`see Developer's Reference 6.2`,
+			`<p>see <a href="http://localhost/devref/best-pkging-practices.html">Developer's Reference 6.2</a></p>`,
+		},
+		{ // developers reference with section
+			`Developers Reference, section 5.1`,
+			`<p><a href="http://localhost/devref/pkgs.html">Developers Reference, section 5.1</a></p>`,
+		},
+		{ // developer's reference chapter
+			`Developer's Reference chapter 6`,
+			`<p><a href="http://localhost/devref/best-pkging-practices.html">Developer's Reference chapter 6</a></p>`,
+		},
+		{ // lintian see also
+			`developer-reference 6.2`,
+			`<p><a href="http://localhost/devref/best-pkging-practices.html">developer-reference 6.2</a></p>`,
+		},
+		{ // policy version is not a section
+			`Debian Policy 4.6.0`,
+			`<p>Debian Policy 4.6.0</p>`,
+		},
+		{ // unknown chapter
+			`§ 42.1`,
+			`<p>§ 42.1</p>`,
+		},
+		{ // In link label
+			`[§ 4.9](http://another.url)`,
+			`<p><a href="http://another.url">§ 4.9</a></p>`,
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, c.src), func(t *testing.T) {
+			testutil.DoTestCase(
+				markdown,
+				testutil.MarkdownTestCase{
+					No:       i,
+					Markdown: c.src,
+					Expected: c.expected,
+				},
+				t,
+			)
+		})
+	}
+}
+
+func TestPolicyLinkReferences(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithParserOptions(parser.WithInlineParsers(
+			util.Prioritized(goldmark_ext.NewPolicyLinkParser("p/", "d/"), 500),
+		)),
+	)
+	src := "See § 4.9, Debian Policy chapter 12 and Developer's Reference 6.2."
+	expected := goldmark_ext.References{
+		{Kind: goldmark_ext.RefPolicy, ID: "4.9", URL: "p/ch-source.html#s4.9"},
+		{Kind: goldmark_ext.RefPolicy, ID: "12", URL: "p/ch-docs.html"},
+		{Kind: goldmark_ext.RefDevref, ID: "6.2", URL: "d/best-pkging-practices.html"},
+	}
+	var actual goldmark_ext.References
+	pc := parser.NewContext()
+	goldmark_ext.SetReferences(pc, &actual)
+	if err := markdown.Convert([]byte(src), io.Discard, parser.WithContext(pc)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("\nexpected: %v\nactual  : %v", expected, actual)
+	}
+}
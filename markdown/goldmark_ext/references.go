@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package goldmark_ext
+
+import (
+	"github.com/yuin/goldmark/parser"
+)
+
+var referencesKey = parser.NewContextKey()
+
+// ReferenceKind is the kind of resource targeted by a Reference.
+type ReferenceKind int
+
+const (
+	RefPolicy ReferenceKind = iota
+	RefDevref
+	RefManpage
+	RefBug
+)
+
+func (k ReferenceKind) String() string {
+	switch k {
+	case RefPolicy:
+		return "Debian Policy Manual"
+	case RefDevref:
+		return "Debian Developer's Reference"
+	case RefManpage:
+		return "Manual pages"
+	case RefBug:
+		return "Debian bugs"
+	}
+	return ""
+}
+
+// Reference is a reference to an external resource, found while parsing.
+// The references found inside link labels are recorded too, even though
+// they are not turned into links.
+type Reference struct {
+	Kind ReferenceKind
+	// ID identifies the resource among the ones of the same kind.
+	ID  string
+	URL string
+}
+
+// References is a list of references.
+type References []Reference
+
+// SetReferences sets in pc the list to which the references found while
+// parsing will be appended.
+func SetReferences(pc parser.Context, refs *References) {
+	pc.Set(referencesKey, refs)
+}
+
+// addReference appends ref to the list of references set in pc, if any.
+func addReference(pc parser.Context, ref Reference) {
+	if refs, ok := pc.Get(referencesKey).(*References); ok {
+		*refs = append(*refs, ref)
+	}
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package goldmark_ext
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// tagURLFmt is the format to use when producing the URL of a tag, relative to
+// the root of the website.
+const tagURLFmt = "tags/%s.html"
+
+var (
+	tagLinkRegexp  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9+._/-]*[A-Za-z0-9+]`)
+	tagLinkRootKey = parser.NewContextKey()
+	tagLinkSelfKey = parser.NewContextKey()
+)
+
+// TagSet is a set of tag names.
+type TagSet interface {
+	// Has reports whether name is in the set.
+	Has(name string) bool
+}
+
+// SetTagLinkContext sets in pc the relative path to the root of the website,
+// to use as a prefix of the tag links, as well as the name of the current
+// tag, which will not be linked.
+func SetTagLinkContext(pc parser.Context, root string, self string) {
+	pc.Set(tagLinkRootKey, root)
+	pc.Set(tagLinkSelfKey, self)
+}
+
+type tagLinkParser struct {
+	tags TagSet
+}
+
+// NewTagLinkParser returns a new InlineParser that parses the names of the
+// tags of the given set, and links them to their page.
+func NewTagLinkParser(tags TagSet) parser.InlineParser {
+	return &tagLinkParser{tags}
+}
+
+func (p *tagLinkParser) Trigger() []byte {
+	// ' ' indicates any white spaces and a line head
+	return []byte{' ', '('}
+}
+
+func (p *tagLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if pc.IsInLinkLabel() {
+		return nil
+	}
+	line, segment := block.PeekLine()
+	consumes := 0
+	start := segment.Start
+	switch line[0] {
+	case ' ', '(':
+		line = line[1:]
+		consumes++
+		start++
+	}
+	loc := tagLinkRegexp.FindIndex(line)
+	if loc == nil {
+		return nil
+	}
+	stop := loc[1]
+	name := string(line[:stop])
+	self, _ := pc.Get(tagLinkSelfKey).(string)
+	if name == self || !p.tags.Has(name) {
+		return nil
+	}
+
+	// Create new node
+	root, _ := pc.Get(tagLinkRootKey).(string)
+	text := ast.NewTextSegment(text.NewSegment(start, start+stop))
+	node := ast.NewLink()
+	node.Destination = []byte(root + fmt.Sprintf(tagURLFmt, name))
+	node.AppendChild(node, text)
+
+	// Adjust parser state
+	block.Advance(stop + consumes)
+	if consumes != 0 {
+		s := segment.WithStop(segment.Start + consumes)
+		ast.MergeOrAppendTextSegment(parent, s)
+	}
+	return node
+}
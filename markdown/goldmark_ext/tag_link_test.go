@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package goldmark_ext_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/markdown/goldmark_ext"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+type tagSet map[string]bool
+
+func (s tagSet) Has(name string) bool {
+	return s[name]
+}
+
+func TestTagLink(t *testing.T) {
+	markdown := goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+		goldmark.WithParserOptions(parser.WithInlineParsers(
+			util.Prioritized(goldmark_ext.NewTagLinkParser(tagSet{
+				"test-tag":          true,
+				"self-tag":          true,
+				"teams/js/test-tag": true,
+			}), 500),
+		)),
+	)
+	cases := []struct {
+		src      string
+		expected string
+	}{
+		{ // basic case
+			`see test-tag.`,
+			`<p>see <a href="../tags/test-tag.html">test-tag</a>.</p>`,
+		},
+		{ // line head
+			`test-tag`,
+			`<p><a href="../tags/test-tag.html">test-tag</a></p>`,
+		},
+		{ // namespaced tag
+			`see teams/js/test-tag`,
+			`<p>see <a href="../tags/teams/js/test-tag.html">teams/js/test-tag</a></p>`,
+		},
+		{ // inside <code></code>
+			`see <code>test-tag</code>.`,
+			`<p>see <code><a href="../tags/test-tag.html">test-tag</a></code>.</p>`,
+		},
+		{ // In parenthesis
+			`(test-tag)`,
+			`<p>(<a href="../tags/test-tag.html">test-tag</a>)</p>`,
+		},
+		{ // unknown tag
+			`see unknown-tag.`,
+			`<p>see unknown-tag.</p>`,
+		},
+		{ // longer word
+			`see test-tag-suffix and prefix-test-tag.`,
+			`<p>see test-tag-suffix and prefix-test-tag.</p>`,
+		},
+		{ // current tag
+			`see self-tag.`,
+			`<p>see self-tag.</p>`,
+		},
+		{ // In link label
+			`[test-tag](http://another.url)`,
+			`<p><a href="http://another.url">test-tag</a></p>`,
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, c.src), func(t *testing.T) {
+			pc := parser.NewContext()
+			goldmark_ext.SetTagLinkContext(pc, "../", "self-tag")
+			buf := bytes.Buffer{}
+			if err := markdown.Convert([]byte(c.src), &buf, parser.WithContext(pc)); err != nil {
+				t.Fatal(err)
+			}
+			actual := string(bytes.TrimSpace(buf.Bytes()))
+			if actual != c.expected {
+				t.Fatalf("\nexpected: %s\nactual  : %s", c.expected, actual)
+			}
+		})
+	}
+}
@@ -16,32 +16,8 @@ import (
 )
 
 var (
-	mdInline = goldmark.New(goldmark.WithParser(parser.NewParser(
-		parser.WithBlockParsers(util.Prioritized(parser.NewParagraphParser(), 100)),
-		parser.WithInlineParsers(parser.DefaultInlineParsers()...),
-	)))
-	mdFull = goldmark.New(
-		goldmark.WithParser(parser.NewParser(
-			parser.WithBlockParsers(
-				// adapted from parser.DefaultBlockParsers(), with headings removed
-				util.Prioritized(parser.NewThematicBreakParser(), 200),
-				util.Prioritized(parser.NewListParser(), 300),
-				util.Prioritized(parser.NewListItemParser(), 400),
-				util.Prioritized(goldmark_ext.NewAnyIndentCodeBlockParser(), 500),
-				util.Prioritized(parser.NewFencedCodeBlockParser(), 700),
-				util.Prioritized(parser.NewBlockquoteParser(), 800),
-				util.Prioritized(parser.NewHTMLBlockParser(), 900),
-				util.Prioritized(parser.NewParagraphParser(), 1000),
-			),
-			parser.WithInlineParsers(append(
-				parser.DefaultInlineParsers(),
-				util.Prioritized(goldmark_ext.NewManpageLinkParser(), 1000),
-				util.Prioritized(goldmark_ext.NewBugLinkParser(), 1000),
-			)...),
-			parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
-		)),
-		goldmark.WithRendererOptions(html.WithUnsafe()),
-	)
+	// defaultRenderer is the Renderer used by ToHTML.
+	defaultRenderer = NewRenderer(Options{})
 	// htmlEntReplacer is a strings.Replacer that transform some HTML entities
 	// into their unicode representation.
 	htmlEntReplacer = strings.NewReplacer(
@@ -59,12 +35,91 @@ const (
 	StyleFull
 )
 
-func ToHTML(src string, style Style) template.HTML {
+// Options configures a Renderer.
+type Options struct {
+	// Tags is the set of the known tags, whose names will be linked to their
+	// page. If nil, no tag links are produced.
+	Tags goldmark_ext.TagSet
+	// PolicyURL is the base URL of the Debian Policy Manual, defaults to
+	// goldmark_ext.DefaultPolicyURL.
+	PolicyURL string
+	// DevrefURL is the base URL of the Debian Developer's Reference, defaults
+	// to goldmark_ext.DefaultDevrefURL.
+	DevrefURL string
+}
+
+// Page holds the information about the page in which Markdown is rendered.
+type Page struct {
+	// Root is the relative path from the page to the root of the website.
+	Root string
+	// Tag is the name of the tag described by the page, if any.
+	Tag string
+	// References, if not nil, collects the references to external resources
+	// found in the rendered Markdown.
+	References *goldmark_ext.References
+}
+
+// Renderer converts Markdown to HTML, using the context of a build.
+// It is safe for concurrent use.
+type Renderer struct {
+	inline goldmark.Markdown
+	full   goldmark.Markdown
+}
+
+// NewRenderer returns a new Renderer configured with opts.
+func NewRenderer(opts Options) *Renderer {
+	if opts.PolicyURL == "" {
+		opts.PolicyURL = goldmark_ext.DefaultPolicyURL
+	}
+	if opts.DevrefURL == "" {
+		opts.DevrefURL = goldmark_ext.DefaultDevrefURL
+	}
+	inlineParsers := []util.PrioritizedValue{
+		util.Prioritized(goldmark_ext.NewManpageLinkParser(), 1000),
+		util.Prioritized(goldmark_ext.NewBugLinkParser(), 1000),
+		util.Prioritized(goldmark_ext.NewPolicyLinkParser(opts.PolicyURL, opts.DevrefURL), 1000),
+	}
+	if opts.Tags != nil {
+		inlineParsers = append(inlineParsers, util.Prioritized(goldmark_ext.NewTagLinkParser(opts.Tags), 1000))
+	}
+	return &Renderer{
+		inline: goldmark.New(goldmark.WithParser(parser.NewParser(
+			parser.WithBlockParsers(util.Prioritized(parser.NewParagraphParser(), 100)),
+			parser.WithInlineParsers(append(parser.DefaultInlineParsers(), inlineParsers...)...),
+		))),
+		full: goldmark.New(
+			goldmark.WithParser(parser.NewParser(
+				parser.WithBlockParsers(
+					// adapted from parser.DefaultBlockParsers(), with headings removed
+					util.Prioritized(parser.NewThematicBreakParser(), 200),
+					util.Prioritized(parser.NewListParser(), 300),
+					util.Prioritized(parser.NewListItemParser(), 400),
+					util.Prioritized(goldmark_ext.NewAnyIndentCodeBlockParser(), 500),
+					util.Prioritized(parser.NewFencedCodeBlockParser(), 700),
+					util.Prioritized(parser.NewBlockquoteParser(), 800),
+					util.Prioritized(parser.NewHTMLBlockParser(), 900),
+					util.Prioritized(parser.NewParagraphParser(), 1000),
+				),
+				parser.WithInlineParsers(append(parser.DefaultInlineParsers(), inlineParsers...)...),
+				parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
+			)),
+			goldmark.WithRendererOptions(html.WithUnsafe()),
+		),
+	}
+}
+
+// ToHTML converts the Markdown src to HTML, for the given page.
+func (r *Renderer) ToHTML(src string, style Style, page Page) template.HTML {
 	var err error
 	buf := bytes.Buffer{}
+	pc := parser.NewContext()
+	goldmark_ext.SetTagLinkContext(pc, page.Root, page.Tag)
+	if page.References != nil {
+		goldmark_ext.SetReferences(pc, page.References)
+	}
 	switch style {
 	case StyleInline:
-		err = mdInline.Convert([]byte(src), &buf)
+		err = r.inline.Convert([]byte(src), &buf, parser.WithContext(pc))
 	case StyleFull:
 		// Lintian tags explanation have had their underscores (_) replaced by
 		// &lowbar; in lintian#d590cbf22, as well as some other special chars,
@@ -72,7 +127,7 @@ func ToHTML(src string, style Style) template.HTML {
 		// rendering markdown code blocks, so we simply replace them back, as
 		// they will be escaped as needed by goldmark.
 		src = htmlEntReplacer.Replace(src)
-		err = mdFull.Convert([]byte(src), &buf)
+		err = r.full.Convert([]byte(src), &buf, parser.WithContext(pc))
 	}
 	if err != nil {
 		// As we use a bytes.Buffer, goldmark.Convert should never return errors.
@@ -80,3 +135,8 @@ func ToHTML(src string, style Style) template.HTML {
 	}
 	return template.HTML(buf.String())
 }
+
+// ToHTML converts the Markdown src to HTML, without any build context.
+func ToHTML(src string, style Style) template.HTML {
+	return defaultRenderer.ToHTML(src, style, Page{})
+}
@@ -98,3 +98,32 @@ func TestDataFull(t *testing.T) {
 		})
 	}
 }
+
+type tagSet map[string]bool
+
+func (s tagSet) Has(name string) bool {
+	return s[name]
+}
+
+func TestRendererTagLinks(t *testing.T) {
+	md := markdown.NewRenderer(markdown.Options{Tags: tagSet{"test-tag": true, "self-tag": true}})
+	page := markdown.Page{Root: "../", Tag: "self-tag"}
+	cases := []struct {
+		src      string
+		style    markdown.Style
+		expected string
+	}{
+		{"see test-tag", markdown.StyleInline, "<p>see <a href=\"../tags/test-tag.html\">test-tag</a></p>\n"},
+		{"see test-tag", markdown.StyleFull, "<p>see <a href=\"../tags/test-tag.html\">test-tag</a></p>\n"},
+		{"see self-tag", markdown.StyleFull, "<p>see self-tag</p>\n"},
+		{"    test-tag", markdown.StyleFull, "<pre><code>test-tag</code></pre>\n"},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, c.src), func(t *testing.T) {
+			actual := string(md.ToHTML(c.src, c.style, page))
+			if actual != c.expected {
+				t.Fatalf("\nexpected: %q\nactual  : %q", c.expected, actual)
+			}
+		})
+	}
+}
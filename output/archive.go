@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package output
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Tar is a Writer that writes the files in a tarball, optionally compressed
+// with gzip.
+type Tar struct {
+	mu      sync.Mutex
+	tw      *tar.Writer
+	closers []io.Closer // closed in order after tw
+	modTime time.Time
+}
+
+// NewTar returns a new Tar that writes the tarball in w.
+func NewTar(w io.Writer, compress bool) *Tar {
+	t := &Tar{modTime: time.Now()}
+	if compress {
+		gw := gzip.NewWriter(w)
+		w = gw
+		t.closers = append(t.closers, gw)
+	}
+	t.tw = tar.NewWriter(w)
+	return t
+}
+
+// CreateTar creates the file located at path and returns a new Tar that
+// writes the tarball in it.
+func CreateTar(path string, compress bool) (*Tar, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	t := NewTar(file, compress)
+	t.closers = append(t.closers, file)
+	return t, nil
+}
+
+func (t *Tar) WriteFile(name string, r io.Reader) error {
+	content, err := readAll(r)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+		ModTime:  t.modTime,
+	}
+	if err := t.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = t.tw.Write(content)
+	return err
+}
+
+func (t *Tar) Close() error {
+	err := t.tw.Close()
+	for _, closer := range t.closers {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Zip is a Writer that writes the files in a zip archive.
+type Zip struct {
+	mu      sync.Mutex
+	zw      *zip.Writer
+	closer  io.Closer
+	modTime time.Time
+}
+
+// NewZip returns a new Zip that writes the archive in w.
+func NewZip(w io.Writer) *Zip {
+	return &Zip{zw: zip.NewWriter(w), modTime: time.Now()}
+}
+
+// CreateZip creates the file located at path and returns a new Zip that
+// writes the archive in it.
+func CreateZip(path string) (*Zip, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	z := NewZip(file)
+	z.closer = file
+	return z, nil
+}
+
+func (z *Zip) WriteFile(name string, r io.Reader) error {
+	content, err := readAll(r)
+	if err != nil {
+		return err
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	w, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: z.modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+func (z *Zip) Close() error {
+	err := z.zw.Close()
+	if z.closer != nil {
+		if cerr := z.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package output provides the destinations in which the files of the website
+// can be written: a directory, an in-memory map or an archive.
+package output
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/n-peugnet/lintian-ssg/ioutil"
+)
+
+// Writer is a destination of the files of the website. Its methods must be
+// safe for concurrent use.
+type Writer interface {
+	// WriteFile writes the content of r in the file located at name, a slash
+	// separated path relative to the root of the destination.
+	WriteFile(name string, r io.Reader) error
+	// Close flushes the written files, and releases the resources held by
+	// the Writer.
+	Close() error
+}
+
+// IsArchive reports whether Open returns an archive for the given path,
+// according to its extension.
+func IsArchive(path string) bool {
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Open returns the Writer for the given path, according to its extension:
+// a gzipped tarball for ".tar.gz" and ".tgz", a tarball for ".tar", a zip
+// archive for ".zip", and a directory otherwise.
+func Open(path string) (Writer, error) {
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return CreateTar(path, true)
+	case strings.HasSuffix(path, ".tar"):
+		return CreateTar(path, false)
+	case strings.HasSuffix(path, ".zip"):
+		return CreateZip(path)
+	default:
+		return Dir(path), nil
+	}
+}
+
+// Dir is a Writer that writes the files in a directory, creating the required
+// subdirectories.
+type Dir string
+
+func (d Dir) WriteFile(name string, r io.Reader) error {
+	return ioutil.WriteFile(string(d), name, r)
+}
+
+func (d Dir) Close() error {
+	return nil
+}
+
+// Memory is a Writer that keeps the files in memory.
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemory returns a new empty Memory.
+func NewMemory() *Memory {
+	return &Memory{files: make(map[string][]byte)}
+}
+
+func (m *Memory) WriteFile(name string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path.Clean(name)] = content
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// ReadFile returns the content of the file located at name, and whether it
+// exists.
+func (m *Memory) ReadFile(name string) ([]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	content, ok := m.files[path.Clean(name)]
+	return content, ok
+}
+
+// readAll reads the content of r, to know its size before writing it in an
+// archive.
+func readAll(r io.Reader) ([]byte, error) {
+	if buf, ok := r.(*bytes.Buffer); ok {
+		return buf.Bytes(), nil
+	}
+	return io.ReadAll(r)
+}
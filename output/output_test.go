@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package output_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/output"
+)
+
+var files = map[string]string{
+	"index.html":         "<p>index</p>",
+	"tags/test-tag.html": "<p>test</p>",
+}
+
+func writeFiles(t *testing.T, w output.Writer) {
+	for name, content := range files {
+		if err := w.WriteFile(name, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, output.Dir(dir))
+	actual := make(map[string]string)
+	for name := range files {
+		content, err := fs.ReadFile(os.DirFS(dir), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual[name] = string(content)
+	}
+	if !reflect.DeepEqual(files, actual) {
+		t.Errorf("\nexpected: %v\nactual  : %v", files, actual)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m := output.NewMemory()
+	writeFiles(t, m)
+	for name, expected := range files {
+		if content, ok := m.ReadFile(name); !ok || string(content) != expected {
+			t.Errorf("unexpected content of %s: %q", name, content)
+		}
+	}
+	if _, ok := m.ReadFile("missing.html"); ok {
+		t.Error("expected missing file not to exist")
+	}
+}
+
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	actual := make(map[string]string)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return actual
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual[header.Name] = string(content)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name string
+		read func(t *testing.T, path string) map[string]string
+	}{
+		{"site.tar", func(t *testing.T, path string) map[string]string {
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			return readTar(t, file)
+		}},
+		{"site.tar.gz", func(t *testing.T, path string) map[string]string {
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			gr, err := gzip.NewReader(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return readTar(t, gr)
+		}},
+		{"site.zip", func(t *testing.T, path string) map[string]string {
+			zr, err := zip.OpenReader(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zr.Close()
+			actual := make(map[string]string)
+			for _, f := range zr.File {
+				content, err := fs.ReadFile(zr, f.Name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				actual[f.Name] = string(content)
+			}
+			return actual
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(dir, c.name)
+			w, err := output.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeFiles(t, w)
+			if actual := c.read(t, path); !reflect.DeepEqual(files, actual) {
+				t.Errorf("\nexpected: %v\nactual  : %v", files, actual)
+			}
+		})
+	}
+	w, err := output.Open(filepath.Join(dir, "site"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := w.(output.Dir); !ok {
+		t.Errorf("expected a Dir, got: %T", w)
+	}
+}
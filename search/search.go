@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package search builds a compact inverted index of documents, to be used by
+// a client-side full-text search.
+package search
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenRegexp matches HTML tags, HTML entities and words. Only the words are
+// kept as tokens.
+var tokenRegexp = regexp.MustCompile(`<[^>]*>|&\w+;|[\p{L}\p{N}]+`)
+
+// stopWords are the words that are too common to be indexed.
+var stopWords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true,
+	"as": true, "at": true, "be": true, "by": true, "can": true,
+	"for": true, "from": true, "has": true, "have": true, "if": true,
+	"in": true, "is": true, "it": true, "its": true, "may": true,
+	"not": true, "of": true, "on": true, "or": true, "should": true,
+	"so": true, "such": true, "that": true, "the": true, "their": true,
+	"then": true, "there": true, "these": true, "this": true, "to": true,
+	"was": true, "which": true, "will": true, "with": true, "you": true,
+}
+
+// StopWords returns the sorted list of the words that are too common to be
+// indexed.
+func StopWords() []string {
+	words := make([]string, 0, len(stopWords))
+	for word := range stopWords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Tokenize returns the lowercased words of text, ignoring HTML markup and
+// stop words.
+func Tokenize(text string) []string {
+	tokens := make([]string, 0)
+	for _, match := range tokenRegexp.FindAllString(text, -1) {
+		if match[0] == '<' || match[0] == '&' {
+			continue
+		}
+		token := strings.ToLower(match)
+		if utf8.RuneCountInString(token) < 2 || stopWords[token] {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// Index is an inverted index that maps each term to the documents that
+// contain it, along with a score.
+type Index struct {
+	docs  []string
+	terms map[string]map[int]int
+}
+
+// NewIndex returns a new empty Index.
+func NewIndex() *Index {
+	return &Index{terms: make(map[string]map[int]int)}
+}
+
+// AddDocument registers a new document and returns its identifier.
+func (idx *Index) AddDocument(name string) int {
+	idx.docs = append(idx.docs, name)
+	return len(idx.docs) - 1
+}
+
+// Add indexes the words of text for the given document. Each occurrence of
+// a word increases the score of the document for this term by weight.
+func (idx *Index) Add(doc int, text string, weight int) {
+	for _, token := range Tokenize(text) {
+		idx.AddTerm(doc, token, weight)
+	}
+}
+
+// AddTerm increases the score of the document for term by weight.
+func (idx *Index) AddTerm(doc int, term string, weight int) {
+	postings, ok := idx.terms[term]
+	if !ok {
+		postings = make(map[int]int)
+		idx.terms[term] = postings
+	}
+	postings[doc] += weight
+}
+
+// MarshalJSON encodes the index in a compact form:
+//
+//	{"docs":["name0","name1"],"terms":{"term":[doc,score,doc,score]},"stopWords":["a"]}
+//
+// where the postings of each term are sorted by decreasing score. The stop
+// words are included so that the queries can be tokenized the same way.
+func (idx *Index) MarshalJSON() ([]byte, error) {
+	terms := make(map[string][]int, len(idx.terms))
+	for term, postings := range idx.terms {
+		docs := make([]int, 0, len(postings))
+		for doc := range postings {
+			docs = append(docs, doc)
+		}
+		sort.Slice(docs, func(i, j int) bool {
+			if postings[docs[i]] != postings[docs[j]] {
+				return postings[docs[i]] > postings[docs[j]]
+			}
+			return docs[i] < docs[j]
+		})
+		flat := make([]int, 0, 2*len(docs))
+		for _, doc := range docs {
+			flat = append(flat, doc, postings[doc])
+		}
+		terms[term] = flat
+	}
+	docs := idx.docs
+	if docs == nil {
+		docs = []string{}
+	}
+	return json.Marshal(struct {
+		Docs      []string         `json:"docs"`
+		Terms     map[string][]int `json:"terms"`
+		StopWords []string         `json:"stopWords"`
+	}{docs, terms, StopWords()})
+}
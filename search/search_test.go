@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package search_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/n-peugnet/lintian-ssg/search"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"The package ships an executable file in /usr/lib.", []string{"package", "ships", "executable", "file", "usr", "lib"}},
+		{"Please move it to <code>/usr/libexec</code>.", []string{"please", "move", "usr", "libexec"}},
+		{"debian&lowbar;rules &lt;x&gt; FHS 3.0", []string{"debian", "rules", "fhs"}},
+		{"Übersetzung", []string{"übersetzung"}},
+		{"é ü 日 本語", []string{"本語"}},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("%d %s", i, c.text), func(t *testing.T) {
+			actual := search.Tokenize(c.text)
+			if !reflect.DeepEqual(c.expected, actual) {
+				t.Fatalf("\nexpected: %q\nactual  : %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+// stopWordsJSON returns the JSON encoding of the stop words.
+func stopWordsJSON(t *testing.T) string {
+	words, err := json.Marshal(search.StopWords())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(words)
+}
+
+func TestStopWords(t *testing.T) {
+	words := search.StopWords()
+	if !sort.StringsAreSorted(words) {
+		t.Errorf("expected stop words to be sorted: %q", words)
+	}
+	for _, word := range words {
+		if tokens := search.Tokenize(word); len(tokens) != 0 {
+			t.Errorf("expected stop word %q not to be tokenized, got: %q", word, tokens)
+		}
+	}
+}
+
+func TestIndexMarshalJSON(t *testing.T) {
+	idx := search.NewIndex()
+	a := idx.AddDocument("tag-a")
+	b := idx.AddDocument("tag-b")
+	idx.AddTerm(a, "tag-a", 10)
+	idx.Add(a, "usr lib", 1)
+	idx.Add(b, "usr libexec usr", 2)
+	actual, err := json.Marshal(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"docs":["tag-a","tag-b"],"terms":{"lib":[0,1],"libexec":[1,2],"tag-a":[0,10],"usr":[1,4,0,1]},"stopWords":` + stopWordsJSON(t) + `}`
+	if string(actual) != expected {
+		t.Fatalf("\nexpected: %s\nactual  : %s", expected, actual)
+	}
+}
+
+func TestEmptyIndexMarshalJSON(t *testing.T) {
+	actual, err := json.Marshal(search.NewIndex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"docs":[],"terms":{},"stopWords":` + stopWordsJSON(t) + `}`
+	if string(actual) != expected {
+		t.Fatalf("\nexpected: %s\nactual  : %s", expected, actual)
+	}
+}
@@ -0,0 +1,105 @@
+// lintian-ssg, a static site generator for lintian tags explanations.
+//
+// Copyright (C) Nicolas Peugnet <nicolas@club1.fr>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/n-peugnet/lintian-ssg/output"
+	"github.com/n-peugnet/lintian-ssg/serve"
+	"github.com/n-peugnet/lintian-ssg/ssg"
+	"github.com/n-peugnet/lintian-ssg/watch"
+)
+
+// buildMemory builds the website configured by opts in memory.
+func buildMemory(ctx context.Context, opts ssg.Options) (*output.Memory, error) {
+	site := output.NewMemory()
+	opts.Output = site
+	generator, err := ssg.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return site, generator.Run(ctx)
+}
+
+// serveSite builds the website in memory and serves it on addr, rebuilding it
+// and reloading the browsers when one of the watched paths changes, until
+// ctx is done.
+func serveSite(ctx context.Context, opts ssg.Options, addr string, watched []string) error {
+	site, err := buildMemory(ctx, opts)
+	if err != nil {
+		return err
+	}
+	server := serve.New(site)
+
+	events, stopWatching, err := watchPaths(watched)
+	if errors.Is(err, watch.ErrUnsupported) {
+		opts.Log.Println("WARNING: live reload disabled:", err)
+	} else if err != nil {
+		return err
+	} else {
+		defer stopWatching()
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("%w: serve: %v", ssg.ErrOutput, err)
+	}
+	httpServer := &http.Server{Handler: server}
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.Serve(listener)
+	}()
+	opts.Log.Printf("serving on http://%s/", listener.Addr())
+
+	rebuilds := make(chan []string)
+	if events != nil {
+		go func() {
+			for paths := watch.Batch(events, rebuildDelay); paths != nil; paths = watch.Batch(events, rebuildDelay) {
+				select {
+				case rebuilds <- paths:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	for {
+		select {
+		case paths := <-rebuilds:
+			site, err := buildMemory(ctx, opts)
+			if err != nil {
+				opts.Log.Println("ERROR: rebuild:", err)
+				continue
+			}
+			server.Update(site)
+			opts.Log.Printf("rebuilt after changes of %v", paths)
+		case err := <-served:
+			return fmt.Errorf("%w: serve: %v", ssg.ErrOutput, err)
+		case <-ctx.Done():
+			// The event streams never end by themselves, so the
+			// connections are closed instead of waiting for them.
+			httpServer.Close()
+			return nil
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package serve serves a website built in memory over HTTP, and reloads the
+// browsers displaying it when it is rebuilt.
+package serve
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/n-peugnet/lintian-ssg/output"
+)
+
+// EventsPath is the path of the server-sent events endpoint notifying the
+// browsers that the website has been rebuilt.
+const EventsPath = "/.lintian-ssg/events"
+
+// reloadScript is injected in the HTML pages to reload them when the website
+// is rebuilt.
+const reloadScript = `<script>new EventSource("` + EventsPath + `").onmessage = () => location.reload();</script>
+`
+
+// Server is a http.Handler serving the files of a website built in memory,
+// with the same semantics as the recommended server configs: the .html
+// extension can be omitted, and 404.html is used as error page.
+type Server struct {
+	mu    sync.RWMutex
+	site  *output.Memory
+	built chan struct{}
+}
+
+// New returns a new Server serving site.
+func New(site *output.Memory) *Server {
+	return &Server{site: site, built: make(chan struct{})}
+}
+
+// Update replaces the served website by site, and reloads the browsers.
+func (s *Server) Update(site *output.Memory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.site = site
+	close(s.built)
+	s.built = make(chan struct{})
+}
+
+// lookup returns the name and content of the file corresponding to the
+// request path p, and whether it is the index of a directory requested
+// without its trailing slash.
+func (s *Server) lookup(p string) (name string, content []byte, dir bool, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	name = strings.TrimPrefix(path.Clean(p), "/")
+	if strings.HasSuffix(p, "/") {
+		content, ok = s.site.ReadFile(path.Join(name, "index.html"))
+		return path.Join(name, "index.html"), content, false, ok
+	}
+	if content, ok = s.site.ReadFile(name); ok {
+		return name, content, false, true
+	}
+	if content, ok = s.site.ReadFile(name + ".html"); ok {
+		return name + ".html", content, false, true
+	}
+	content, ok = s.site.ReadFile(path.Join(name, "index.html"))
+	return path.Join(name, "index.html"), content, ok, ok
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == EventsPath {
+		s.serveEvents(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	status := http.StatusOK
+	name, content, dir, ok := s.lookup(r.URL.Path)
+	if dir {
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+	if !ok {
+		status = http.StatusNotFound
+		name, content, _, ok = s.lookup("/404.html")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+	if strings.HasPrefix(contentType, "text/html") {
+		content = injectReload(content)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write(content)
+	}
+}
+
+// injectReload returns a copy of the HTML page content with the reload script
+// added at the end of its body.
+func injectReload(content []byte) []byte {
+	i := bytes.LastIndex(content, []byte("</body>"))
+	if i < 0 {
+		i = len(content)
+	}
+	page := make([]byte, 0, len(content)+len(reloadScript))
+	page = append(page, content[:i]...)
+	page = append(page, reloadScript...)
+	return append(page, content[i:]...)
+}
+
+// serveEvents sends an event to the browser when the website is rebuilt.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	s.mu.RLock()
+	built := s.built
+	s.mu.RUnlock()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	select {
+	case <-built:
+		fmt.Fprint(w, "data: reload\n\n")
+		flusher.Flush()
+	case <-r.Context().Done():
+	}
+}
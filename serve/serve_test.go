@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package serve_test
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/n-peugnet/lintian-ssg/output"
+	"github.com/n-peugnet/lintian-ssg/serve"
+)
+
+func newSite(t *testing.T, files map[string]string) *output.Memory {
+	site := output.NewMemory()
+	for name, content := range files {
+		if err := site.WriteFile(name, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return site
+}
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(serve.New(newSite(t, map[string]string{
+		"index.html":         "<body>index</body>",
+		"404.html":           "<body>not found</body>",
+		"main.css":           "body {}",
+		"tags/test-tag.html": "<body>test</body>",
+		"latest/index.html":  "<body>latest</body>",
+	})))
+	defer server.Close()
+
+	cases := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"/", http.StatusOK, "text/html", "<body>index"},
+		{"/tags/test-tag", http.StatusOK, "text/html", "<body>test"},
+		{"/tags/test-tag.html", http.StatusOK, "text/html", "<body>test"},
+		{"/latest", http.StatusOK, "text/html", "<body>latest"},
+		{"/main.css", http.StatusOK, "text/css", "body {}"},
+		{"/tags/missing", http.StatusNotFound, "text/html", "<body>not found"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			resp, err := http.Get(server.URL + c.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != c.status {
+				t.Errorf("expected status %d, got: %d", c.status, resp.StatusCode)
+			}
+			if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, c.contentType) {
+				t.Errorf("expected content type %q, got: %q", c.contentType, contentType)
+			}
+			if !strings.HasPrefix(string(body), c.body) {
+				t.Errorf("expected body to start with %q, got: %q", c.body, body)
+			}
+			isHTML := strings.HasPrefix(c.contentType, "text/html")
+			if hasScript := strings.Contains(string(body), serve.EventsPath); hasScript != isHTML {
+				t.Errorf("expected reload script injected: %v, got: %q", isHTML, body)
+			}
+		})
+	}
+}
+
+func TestServerReload(t *testing.T) {
+	handler := serve.New(newSite(t, map[string]string{"index.html": "old"}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + serve.EventsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("unexpected content type: %q", contentType)
+	}
+	handler.Update(newSite(t, map[string]string{"index.html": "new"}))
+
+	lines := make(chan string)
+	go func() {
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		if line != "data: reload\n" {
+			t.Errorf("unexpected event: %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload event received")
+	}
+
+	resp, err = http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(body), "new") {
+		t.Errorf("expected updated site, got: %q", body)
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"strings"
+
+	"github.com/n-peugnet/lintian-ssg/diff"
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+type changesTmplParams struct {
+	tmplParams
+	*lintian.Changes
+	ExplanationDiffs []explanationDiff
+}
+
+type explanationDiff struct {
+	Tag      string
+	DiffHTML template.HTML
+}
+
+// diffHTML renders the edits as HTML, the inserted and deleted parts being
+// enclosed in <ins> and <del> elements.
+func diffHTML(edits []diff.Edit) template.HTML {
+	builder := strings.Builder{}
+	for _, edit := range edits {
+		text := template.HTMLEscapeString(edit.Text)
+		switch edit.Op {
+		case diff.Insert:
+			builder.WriteString("<ins>" + text + "</ins>")
+		case diff.Delete:
+			builder.WriteString("<del>" + text + "</del>")
+		default:
+			builder.WriteString(text)
+		}
+	}
+	return template.HTML(builder.String())
+}
+
+// writeChanges writes a page and a JSON file listing the changes of the tags
+// between the previous lintian version and the one of set.
+func writeChanges(tmpl *template.Template, params *tmplParams, prev *tagSet, set *tagSet, out *site) error {
+	changes := lintian.CompareTags(prev.tags, set.tags)
+	changes.From = prev.version
+	changes.To = set.version
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	if err := out.writeFile("changes.json", bytes.NewReader(changesJSON)); err != nil {
+		return err
+	}
+	page := "changes.html"
+	changesParams := changesTmplParams{
+		tmplParams:       withRoot(*params, rootRelPath(page)),
+		Changes:          changes,
+		ExplanationDiffs: make([]explanationDiff, len(changes.Explanation)),
+	}
+	for i, change := range changes.Explanation {
+		changesParams.ExplanationDiffs[i] = explanationDiff{change.Tag, diffHTML(diff.Words(change.From, change.To))}
+	}
+	return writeSimplePage(tmpl, &changesParams, page, out)
+}
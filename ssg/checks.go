@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"html/template"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+type checkTmplParams struct {
+	tmplParams
+	Check  string
+	Source string
+	Tags   []*lintian.Tag
+}
+
+type checksTmplParams struct {
+	tmplParams
+	Tree []*checkNode
+}
+
+// checkNode is a node of the tree of checks, built from their paths.
+type checkNode struct {
+	Name     string
+	Path     string
+	Count    int // number of tags of the check, zero if it is not a check
+	Children []*checkNode
+}
+
+// buildCheckTree returns the roots of the tree of the given checks, each
+// level being sorted by name.
+func buildCheckTree(checks map[string][]*lintian.Tag) []*checkNode {
+	root := &checkNode{}
+	nodes := map[string]*checkNode{"": root}
+	var getNode func(p string) *checkNode
+	getNode = func(p string) *checkNode {
+		if node, ok := nodes[p]; ok {
+			return node
+		}
+		dir, name := path.Split(p)
+		parent := getNode(strings.TrimSuffix(dir, "/"))
+		node := &checkNode{Name: name, Path: p}
+		parent.Children = append(parent.Children, node)
+		nodes[p] = node
+		return node
+	}
+	for check, tags := range checks {
+		getNode(check).Count = len(tags)
+	}
+	for _, node := range nodes {
+		sort.Slice(node.Children, func(i, j int) bool {
+			return node.Children[i].Name < node.Children[j].Name
+		})
+	}
+	return root.Children
+}
+
+// writeChecks writes a page for each check, listing all its tags, as well as
+// an index of all the checks.
+func writeChecks(checkTmpl *template.Template, checksTmpl *template.Template, params *tmplParams, checks map[string][]*lintian.Tag, out *site) error {
+	for check, tags := range checks {
+		sort.Slice(tags, func(i, j int) bool {
+			return tags[i].Name < tags[j].Name
+		})
+		page := path.Join("checks", check+".html")
+		checkParams := checkTmplParams{
+			tmplParams: withRoot(*params, rootRelPath(page)),
+			Check:      check,
+			Source:     tags[0].CheckSource(),
+			Tags:       tags,
+		}
+		if err := writeSimplePage(checkTmpl, &checkParams, page, out); err != nil {
+			return err
+		}
+	}
+	page := "checks/index.html"
+	checksParams := checksTmplParams{withRoot(*params, rootRelPath(page)), buildCheckTree(checks)}
+	return writeSimplePage(checksTmpl, &checksParams, page, out)
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrInput is matched by the errors that occurred while reading the
+	// inputs: tags, manual or templates.
+	ErrInput = errors.New("input error")
+	// ErrOutput is matched by the errors that occurred while writing the
+	// website.
+	ErrOutput = errors.New("output error")
+
+	// errSourcesRead is returned when Options.Sources are read by a
+	// Generator that has already consumed them.
+	errSourcesRead = errors.New("sources have already been read")
+)
+
+// buildError is an error of a stage of the build, along with its kind, either
+// ErrInput or ErrOutput.
+type buildError struct {
+	kind error
+	op   string
+	err  error
+}
+
+func (e *buildError) Error() string {
+	return e.op + ": " + e.err.Error()
+}
+
+func (e *buildError) Unwrap() error {
+	return e.err
+}
+
+func (e *buildError) Is(target error) bool {
+	return target == e.kind
+}
+
+// newBuildError returns err as an error of the operation op, or nil if err is
+// nil. If err already is a buildError, its kind is kept.
+func newBuildError(kind error, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var buildErr *buildError
+	if errors.As(err, &buildErr) {
+		kind = buildErr.kind
+	}
+	return &buildError{kind, op, err}
+}
+
+// inputError returns err as an error of the operation op on the inputs, or
+// nil if err is nil.
+func inputError(op string, err error) error {
+	return newBuildError(ErrInput, op, err)
+}
+
+// outputError returns err as an error of the operation op on the output, or
+// nil if err is nil.
+func outputError(op string, err error) error {
+	return newBuildError(ErrOutput, op, err)
+}
+
+// group runs functions in goroutines and collects the first error returned by
+// them, cancelling its context, with the same semantics as errgroup.Group.
+type group struct {
+	wg     sync.WaitGroup
+	sem    chan struct{} // limits the number of active goroutines, if not nil
+	cancel context.CancelFunc
+	once   sync.Once
+	err    error
+}
+
+// newGroup returns a new group and a context derived from ctx, which is
+// cancelled as soon as a function of the group returns an error, or once
+// wait returns.
+func newGroup(ctx context.Context) (*group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &group{cancel: cancel}, ctx
+}
+
+// setLimit limits the number of functions of the group running concurrently
+// to n. A negative value removes the limit. It must not be called while
+// functions are running.
+func (g *group) setLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// run calls fn in a new goroutine. If the limit of the group is reached, it
+// blocks until one of the running functions returns.
+func (g *group) run(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+		if err := fn(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// wait waits for all the functions of the group to return, then returns the
+// first error, if any.
+func (g *group) wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+	"github.com/n-peugnet/lintian-ssg/version"
+)
+
+const feedPath = "feed.xml"
+
+type atomFeed struct {
+	XMLName   xml.Name      `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string        `xml:"title"`
+	ID        string        `xml:"id"`
+	Updated   string        `xml:"updated"`
+	Author    atomAuthor    `xml:"author"`
+	Generator atomGenerator `xml:"generator"`
+	Links     []atomLink    `xml:"link"`
+	Entries   []atomEntry   `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomGenerator struct {
+	URI     string `xml:"uri,attr"`
+	Version string `xml:"version,attr"`
+	Name    string `xml:",chardata"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+	Summary string   `xml:"summary"`
+}
+
+// feedEntries returns an entry for each tag added or changed between the
+// previous lintian version and the one of set, with a summary of its changes.
+func feedEntries(baseURL string, prev *tagSet, set *tagSet) []atomEntry {
+	changes := lintian.CompareTags(prev.tags, set.tags)
+	added := make(map[string]bool, len(changes.Added))
+	summaries := make(map[string][]string)
+	for _, name := range changes.Added {
+		added[name] = true
+		summaries[name] = append(summaries[name], "New tag.")
+	}
+	for _, rename := range changes.Renamed {
+		summaries[rename.To] = append(summaries[rename.To], fmt.Sprintf("Renamed from %s.", rename.From))
+	}
+	for _, change := range changes.Severity {
+		summaries[change.Tag] = append(summaries[change.Tag], fmt.Sprintf("Severity changed from %s to %s.", change.From, change.To))
+	}
+	for _, change := range changes.Experimental {
+		if change.To {
+			summaries[change.Tag] = append(summaries[change.Tag], "Now experimental.")
+		} else {
+			summaries[change.Tag] = append(summaries[change.Tag], "No longer experimental.")
+		}
+	}
+	for _, change := range changes.Explanation {
+		summaries[change.Tag] = append(summaries[change.Tag], "Explanation changed.")
+	}
+	names := make([]string, 0, len(summaries))
+	for name := range summaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	updated := set.date.Format(time.RFC3339)
+	entries := make([]atomEntry, len(names))
+	for i, name := range names {
+		url := baseURL + set.version + "/tags/" + name + ".html"
+		title := name + " changed in lintian " + set.version
+		if added[name] {
+			title = name + " added in lintian " + set.version
+		}
+		entries[i] = atomEntry{
+			Title:   title,
+			ID:      url,
+			Updated: updated,
+			Link:    atomLink{Href: url},
+			Summary: strings.Join(summaries[name], " "),
+		}
+	}
+	return entries
+}
+
+// writeFeed writes an Atom feed at the root of the website, whose entries are
+// the tags added or changed in each lintian version, newest first. The sets
+// must be sorted from the newest version to the oldest.
+func writeFeed(baseURL string, sets []*tagSet, out *site) error {
+	feed := atomFeed{
+		Title:     "Lintian tags changes",
+		ID:        baseURL + feedPath,
+		Author:    atomAuthor{"Lintian maintainers"},
+		Generator: atomGenerator{"https://github.com/n-peugnet/lintian-ssg", version.Number, "lintian-ssg"},
+		Links: []atomLink{
+			{Href: baseURL + feedPath, Rel: "self", Type: "application/atom+xml"},
+			{Href: baseURL + latestDir + "/index.html", Rel: "alternate", Type: "text/html"},
+		},
+		Entries: make([]atomEntry, 0),
+	}
+	var updated time.Time
+	for _, set := range sets {
+		if set.date.After(updated) {
+			updated = set.date
+		}
+		if prev := set.previous(sets); prev != nil {
+			feed.Entries = append(feed.Entries, feedEntries(baseURL, prev, set)...)
+		}
+	}
+	feed.Updated = updated.Format(time.RFC3339)
+	content := bytes.NewBufferString(xml.Header)
+	encoder := xml.NewEncoder(content)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(&feed); err != nil {
+		return err
+	}
+	content.WriteByte('\n')
+	return out.writeFile(feedPath, content)
+}
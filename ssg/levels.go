@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"html/template"
+	"path"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+type listTmplParams struct {
+	tmplParams
+	Title       string
+	Description string
+	Page        string
+	Tags        []*lintian.Tag
+}
+
+// levelCount is the number of tags of a given level.
+type levelCount struct {
+	Level lintian.Level
+	Count int
+}
+
+// countLevels returns the number of tags of each level, as well as the
+// number of experimental tags.
+func countLevels(tags []*lintian.Tag) (counts []levelCount, experimental int) {
+	byLevel := make(map[lintian.Level]int, len(lintian.Levels))
+	for _, tag := range tags {
+		byLevel[tag.Visibility]++
+		if tag.Experimental {
+			experimental++
+		}
+	}
+	counts = make([]levelCount, len(lintian.Levels))
+	for i, level := range lintian.Levels {
+		counts[i] = levelCount{level, byLevel[level]}
+	}
+	return
+}
+
+// writeLevels writes a page for each level listing all the tags of this
+// level, as well as a page listing all the experimental tags.
+func writeLevels(tmpl *template.Template, params *tmplParams, tags []*lintian.Tag, out *site) error {
+	byLevel := make(map[lintian.Level][]*lintian.Tag, len(lintian.Levels))
+	experimental := make([]*lintian.Tag, 0)
+	for _, tag := range tags {
+		byLevel[tag.Visibility] = append(byLevel[tag.Visibility], tag)
+		if tag.Experimental {
+			experimental = append(experimental, tag)
+		}
+	}
+	for _, level := range lintian.Levels {
+		page := path.Join("severities", string(level)+".html")
+		listParams := listTmplParams{
+			tmplParams:  withRoot(*params, rootRelPath(page)),
+			Title:       "Lintian tags of severity " + string(level),
+			Description: "List of all the lintian tags of severity " + string(level),
+			Page:        page,
+			Tags:        byLevel[level],
+		}
+		if err := writeSimplePage(tmpl, &listParams, page, out); err != nil {
+			return err
+		}
+	}
+	page := "experimental.html"
+	listParams := listTmplParams{
+		tmplParams:  withRoot(*params, rootRelPath(page)),
+		Title:       "Experimental lintian tags",
+		Description: "List of all the experimental lintian tags",
+		Page:        page,
+		Tags:        experimental,
+	}
+	return writeSimplePage(tmpl, &listParams, page, out)
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/n-peugnet/lintian-ssg/ioutil"
+)
+
+// manifestName is the name of the file, at the root of the output directory,
+// in which the manifest of the build is saved.
+const manifestName = ".lintian-ssg-manifest.json"
+
+// manifest records the hash of the content of each file written in the output
+// directory, so that the files that did not change since the previous build
+// are not rewritten, and the ones that are not generated anymore are removed.
+// The generation date is excluded from the hashes, so that files are not
+// rewritten only because it changed: the skipped files keep the date of their
+// last rewrite. It is safe for concurrent use.
+//
+// The previous build can be located in another directory, base, in which case
+// the unchanged files are hard linked from it instead of being rewritten.
+type manifest struct {
+	dir     string
+	base    string
+	ignore  [][]byte // strings excluded from the hashes
+	mu      sync.Mutex
+	prev    map[string]string // hashes of the previous build, by path
+	files   map[string]string // hashes of the current build, by path
+	written int
+	skipped int
+	removed int
+}
+
+// loadManifest returns the manifest of the build in dir, initialized with the
+// one of the previous build located in base, if any. The ignored strings are
+// excluded from the hashes of the files.
+func loadManifest(dir string, base string, ignore ...string) (*manifest, error) {
+	m := &manifest{
+		dir:   dir,
+		base:  base,
+		prev:  make(map[string]string),
+		files: make(map[string]string, 4096),
+	}
+	for _, str := range ignore {
+		m.ignore = append(m.ignore, []byte(str))
+	}
+	if base == "" {
+		return m, nil
+	}
+	content, err := os.ReadFile(filepath.Join(base, manifestName))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &m.prev); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// WriteFile writes the content of r in the file located at name in the output
+// directory, unless it already contains the same content as in the previous
+// build.
+func (m *manifest) WriteFile(name string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	hash := m.hash(content)
+	m.mu.Lock()
+	prev := m.prev[name]
+	m.files[name] = hash
+	m.mu.Unlock()
+	if prev == hash && m.reuse(name) {
+		m.count(&m.skipped)
+		return nil
+	}
+	if err := ioutil.WriteFile(m.dir, name, bytes.NewReader(content)); err != nil {
+		return err
+	}
+	m.count(&m.written)
+	return nil
+}
+
+// Keep keeps the file located at name in the previous build, without having
+// to render it again, as its content did not change. The file is written
+// again by the next build if it is not available anymore.
+func (m *manifest) Keep(name string) {
+	m.mu.Lock()
+	hash, ok := m.prev[name]
+	m.mu.Unlock()
+	if !ok || !m.reuse(name) {
+		return
+	}
+	m.mu.Lock()
+	m.files[name] = hash
+	m.mu.Unlock()
+}
+
+// reuse reports whether the file located at name in the previous build is
+// still available in the current one, hard linking it if needed.
+func (m *manifest) reuse(name string) bool {
+	path := filepath.Join(m.dir, name)
+	if m.base == m.dir {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false
+	}
+	return os.Link(filepath.Join(m.base, name), path) == nil
+}
+
+func (m *manifest) hash(content []byte) string {
+	for _, str := range m.ignore {
+		content = bytes.ReplaceAll(content, str, nil)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *manifest) count(counter *int) {
+	m.mu.Lock()
+	*counter++
+	m.mu.Unlock()
+}
+
+// Close removes the files of the previous build that have not been written
+// in this one, along with their directories if they become empty, and saves
+// the manifest. It must be called once all the files have been written.
+// The files are only removed if the previous build is in the same directory.
+func (m *manifest) Close() error {
+	stale := make([]string, 0)
+	for name := range m.prev {
+		if _, ok := m.files[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	for _, name := range stale {
+		m.removed++
+		if m.base != m.dir {
+			continue
+		}
+		path := filepath.Join(m.dir, name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for dir := filepath.Dir(path); dir != m.dir; dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+	content, err := json.Marshal(m.files)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.dir, manifestName), content, 0644)
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// buildNameFmt is the format of the names of the build directories, which
+// sort in chronological order.
+const buildNameFmt = "20060102T150405.000000000Z"
+
+// atomicBuild is a build of the website in a new directory, next to the
+// output one, that replaces it only once complete. The output directory is
+// then a symlink to the current build.
+type atomicBuild struct {
+	out       string // path of the output directory
+	buildsDir string // path of the directory containing the builds
+	dir       string // path of the directory of this build
+	prev      string // path of the directory of the previous build, if any
+}
+
+// newAtomicBuild creates a new build directory for the output directory out,
+// in the sibling directory out.builds.
+func newAtomicBuild(out string, date time.Time) (*atomicBuild, error) {
+	out = filepath.Clean(out)
+	b := &atomicBuild{out: out, buildsDir: out + ".builds"}
+	b.dir = filepath.Join(b.buildsDir, date.UTC().Format(buildNameFmt))
+	if err := os.MkdirAll(b.buildsDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Mkdir(b.dir, 0755); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(out); err == nil {
+		if b.prev, err = filepath.EvalSymlinks(out); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return b, nil
+}
+
+// abort removes the directory of the build.
+func (b *atomicBuild) abort() error {
+	return os.RemoveAll(b.dir)
+}
+
+// publish atomically replaces the output directory by a symlink to the
+// directory of the build. If the output directory is not already a symlink, it
+// is moved into the builds directory, as the oldest build, only once the
+// symlink is ready to replace it, and moved back if this fails.
+func (b *atomicBuild) publish() error {
+	target, err := filepath.Rel(filepath.Dir(b.out), b.dir)
+	if err != nil {
+		return err
+	}
+	tmpLink := b.out + ".tmp"
+	if err := os.Remove(tmpLink); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Symlink(target, tmpLink); err != nil {
+		return err
+	}
+	info, err := os.Lstat(b.out)
+	if err != nil && !os.IsNotExist(err) {
+		os.Remove(tmpLink)
+		return err
+	}
+	moved := ""
+	if err == nil && info.Mode()&os.ModeSymlink == 0 {
+		moved = filepath.Join(b.buildsDir, info.ModTime().UTC().Format(buildNameFmt))
+		if err := os.Rename(b.out, moved); err != nil {
+			os.Remove(tmpLink)
+			return err
+		}
+	}
+	if err := os.Rename(tmpLink, b.out); err != nil {
+		if moved != "" {
+			os.Rename(moved, b.out)
+		}
+		os.Remove(tmpLink)
+		return err
+	}
+	return nil
+}
+
+// prune removes the builds older than the current one, except the keep most
+// recent ones.
+func (b *atomicBuild) prune(keep int) error {
+	entries, err := os.ReadDir(b.buildsDir)
+	if err != nil {
+		return err
+	}
+	current := filepath.Base(b.dir)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() < current {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	for i, name := range names {
+		if i < keep {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(b.buildsDir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+// pageTemplates are the names of the templates that are not used to render
+// the pages of the tags.
+var pageTemplates = map[string]bool{
+	"absent.html.tmpl":     true,
+	"changes.html.tmpl":    true,
+	"manual.html.tmpl":     true,
+	"check.html.tmpl":      true,
+	"checks.html.tmpl":     true,
+	"list.html.tmpl":       true,
+	"search.html.tmpl":     true,
+	"references.html.tmpl": true,
+	"about.html.tmpl":      true,
+	"404.html.tmpl":        true,
+	"redirect.html.tmpl":   true,
+}
+
+// isInside reports whether path is the same as or inside root.
+func isInside(path string, root string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// dirtyTags returns the names of the tags of set whose pages differ from the
+// ones of the tags of prev, and false if the versions or the names of the
+// tags changed, in which case all the pages of the website are affected.
+func dirtyTags(prev *tagSet, set *tagSet) (map[string]bool, bool) {
+	if prev.version != set.version || !reflect.DeepEqual(prev.names, set.names) {
+		return nil, false
+	}
+	prevTags := make(map[string]*lintian.Tag, len(prev.tags))
+	for _, tag := range prev.tags {
+		prevTags[tag.Name] = tag
+	}
+	prevRefs, refs := lintian.NewReferences(prev.tags), lintian.NewReferences(set.tags)
+	dirty := make(map[string]bool)
+	for _, tag := range set.tags {
+		prevTag, ok := prevTags[tag.Name]
+		if !ok {
+			return nil, false
+		}
+		if !reflect.DeepEqual(prevTag, tag) ||
+			!reflect.DeepEqual(prevRefs.ReferencedBy(tag.Name), refs.ReferencedBy(tag.Name)) {
+			dirty[tag.Name] = true
+		}
+	}
+	return dirty, true
+}
+
+// Rebuild updates the website written by the last call to Run, after the
+// files located at paths changed. The paths can be the ones of inputs,
+// or of files inside them for source trees, of the manual, of template
+// overrides or of assets. Only the changed inputs are read again and, as long as the
+// versions and names of the tags did not change, only the pages of the tags
+// affected by the changes are rendered. Rebuild falls back to Run if there is
+// no previous build, or if the website is not written in the output
+// directory, or with Atomic.
+func (g *Generator) Rebuild(ctx context.Context, paths []string) error {
+	start := time.Now()
+	changed := make(map[string]bool)
+	templates, allTags := false, false
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		input := ""
+		for _, in := range g.opts.Inputs {
+			if in != "-" && isInside(path, filepath.Clean(in)) {
+				input = in
+			}
+		}
+		switch {
+		case input != "":
+			changed[input] = true
+		case path == filepath.Clean(g.opts.ManualPath):
+			// The manual is always written.
+		case strings.HasSuffix(path, ".html.tmpl"):
+			templates = true
+			allTags = allTags || !pageTemplates[filepath.Base(path)]
+		default:
+			// The assets are always written.
+		}
+	}
+	if templates {
+		tmpls, err := parseTemplates(g.opts.Templates)
+		if err != nil {
+			return &buildError{ErrInput, "parse templates", err}
+		}
+		g.tmpls = tmpls
+	}
+	if g.sets == nil || g.opts.Output != nil || g.opts.Atomic {
+		return g.Run(ctx)
+	}
+
+	sets := make([]*tagSet, len(g.sets))
+	copy(sets, g.sets)
+	g.sets = nil
+	all := false
+	for i, set := range sets {
+		if !changed[set.input] {
+			set.dirty = make(map[string]bool)
+			continue
+		}
+		newSet, err := g.readInput(set.input, start)
+		if err != nil {
+			return err
+		}
+		dirty, ok := dirtyTags(set, newSet)
+		newSet.dirty, all = dirty, all || !ok
+		sets[i] = newSet
+	}
+	if all && len(sets) > 1 {
+		if err := sortVersions(sets); err != nil {
+			return inputError("multiple inputs", err)
+		}
+	}
+	for _, set := range sets {
+		if all || allTags {
+			set.dirty = nil
+		}
+	}
+	if err := g.build(ctx, start, sets); err != nil {
+		return err
+	}
+	g.sets = sets
+	return nil
+}
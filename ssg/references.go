@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"html/template"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/n-peugnet/lintian-ssg/markdown/goldmark_ext"
+)
+
+type refsTmplParams struct {
+	tmplParams
+	Title       string
+	Description string
+	Page        string
+	Intro       string
+	Groups      []refGroup
+}
+
+// refGroup is a list of references to resources of the same kind.
+type refGroup struct {
+	Title string
+	Refs  []refEntry
+}
+
+// refEntry is a reference to an external resource, with the names of the
+// tags that mention it.
+type refEntry struct {
+	Label string
+	URL   string
+	Tags  []string
+}
+
+// refIndex indexes the references to external resources found in the tags.
+// It is safe for concurrent use.
+type refIndex struct {
+	mu   sync.Mutex
+	refs map[goldmark_ext.ReferenceKind]map[string]*refIndexEntry
+}
+
+type refIndexEntry struct {
+	url  string
+	tags map[string]bool
+}
+
+func newRefIndex() *refIndex {
+	return &refIndex{refs: make(map[goldmark_ext.ReferenceKind]map[string]*refIndexEntry)}
+}
+
+// add records that the tag named tag mentions refs.
+func (idx *refIndex) add(tag string, refs goldmark_ext.References) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, ref := range refs {
+		byID, ok := idx.refs[ref.Kind]
+		if !ok {
+			byID = make(map[string]*refIndexEntry)
+			idx.refs[ref.Kind] = byID
+		}
+		entry, ok := byID[ref.ID]
+		if !ok {
+			entry = &refIndexEntry{url: ref.URL, tags: make(map[string]bool)}
+			byID[ref.ID] = entry
+		}
+		entry.tags[tag] = true
+	}
+}
+
+// remove forgets the references mentioned by the tag named tag.
+func (idx *refIndex) remove(tag string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, byID := range idx.refs {
+		for id, entry := range byID {
+			delete(entry.tags, tag)
+			if len(entry.tags) == 0 {
+				delete(byID, id)
+			}
+		}
+	}
+}
+
+// group returns the references of the given kind, sorted by ID using less,
+// and labeled using label.
+func (idx *refIndex) group(kind goldmark_ext.ReferenceKind, less func(a, b string) bool, label func(id string) string) refGroup {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	byID := idx.refs[kind]
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return less(ids[i], ids[j]) })
+	group := refGroup{Title: kind.String(), Refs: make([]refEntry, len(ids))}
+	for i, id := range ids {
+		entry := byID[id]
+		tags := make([]string, 0, len(entry.tags))
+		for tag := range entry.tags {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		group.Refs[i] = refEntry{label(id), entry.url, tags}
+	}
+	return group
+}
+
+// lessSection reports whether the section number a is before b.
+func lessSection(a, b string) bool {
+	partsA := strings.Split(a, ".")
+	partsB := strings.Split(b, ".")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, _ := strconv.Atoi(partsA[i])
+		numB, _ := strconv.Atoi(partsB[i])
+		if numA != numB {
+			return numA < numB
+		}
+	}
+	return len(partsA) < len(partsB)
+}
+
+// lessNumber reports whether the number a is lower than b.
+func lessNumber(a, b string) bool {
+	numA, _ := strconv.Atoi(a)
+	numB, _ := strconv.Atoi(b)
+	return numA < numB
+}
+
+func lessString(a, b string) bool {
+	return a < b
+}
+
+func labelSection(id string) string {
+	return "§ " + id
+}
+
+func labelBug(id string) string {
+	return "Bug#" + id
+}
+
+func labelIdentity(id string) string {
+	return id
+}
+
+// writeReferences writes the pages listing all the external resources
+// referenced by the tags: the sections of the Debian Policy Manual and
+// Developer's Reference, the manual pages and the Debian bugs.
+func writeReferences(tmpl *template.Template, params *tmplParams, idx *refIndex, out *site) error {
+	refsPages := []refsTmplParams{
+		{
+			Title:       "Debian Policy sections",
+			Description: "List of the Debian Policy and Developer's Reference sections referenced by lintian tags",
+			Page:        "policy/index.html",
+			Intro:       "These are the sections of the Debian Policy Manual and Developer's Reference referenced by the lintian tags.",
+			Groups: []refGroup{
+				idx.group(goldmark_ext.RefPolicy, lessSection, labelSection),
+				idx.group(goldmark_ext.RefDevref, lessSection, labelSection),
+			},
+		},
+		{
+			Title:       "Manual pages",
+			Description: "List of the manual pages referenced by lintian tags",
+			Page:        "references/manpages.html",
+			Intro:       "These are the manual pages referenced by the lintian tags.",
+			Groups:      []refGroup{idx.group(goldmark_ext.RefManpage, lessString, labelIdentity)},
+		},
+		{
+			Title:       "Debian bugs",
+			Description: "List of the Debian bugs referenced by lintian tags",
+			Page:        "references/bugs.html",
+			Intro:       "These are the Debian bugs referenced by the lintian tags.",
+			Groups:      []refGroup{idx.group(goldmark_ext.RefBug, lessNumber, labelBug)},
+		},
+	}
+	for _, refsParams := range refsPages {
+		refsParams.tmplParams = withRoot(*params, rootRelPath(refsParams.Page))
+		if err := writeSimplePage(tmpl, &refsParams, refsParams.Page, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
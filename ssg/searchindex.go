@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+	"github.com/n-peugnet/lintian-ssg/search"
+)
+
+// Weights of the different parts of a tag in the search index.
+const (
+	searchWeightName        = 20
+	searchWeightNameWords   = 10
+	searchWeightSeeAlso     = 2
+	searchWeightExplanation = 1
+	searchWeightScreen      = 1
+)
+
+// searchIndexVar is the name of the JavaScript variable that holds the search
+// index. It is loaded with a script element instead of being fetched, so that
+// the search also works when browsing the site from the filesystem.
+const searchIndexVar = "lintianSearchIndex"
+
+// indexTag adds the relevant content of tag in the search index.
+func indexTag(idx *search.Index, tag *lintian.Tag) {
+	doc := idx.AddDocument(tag.Name)
+	idx.AddTerm(doc, strings.ToLower(tag.Name), searchWeightName)
+	idx.Add(doc, tag.Name, searchWeightNameWords)
+	idx.Add(doc, tag.Explanation, searchWeightExplanation)
+	idx.Add(doc, strings.Join(tag.SeeAlso, " "), searchWeightSeeAlso)
+	for _, screen := range tag.Screens {
+		idx.Add(doc, screen.Name, searchWeightScreen)
+		idx.Add(doc, screen.Reason, searchWeightScreen)
+		idx.Add(doc, strings.Join(screen.SeeAlso, " "), searchWeightScreen)
+	}
+}
+
+func writeSearchIndex(idx *search.Index, path string, out *site) error {
+	indexJSON, err := idx.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	content := bytes.Buffer{}
+	content.Grow(len(indexJSON) + 32)
+	content.WriteString("var " + searchIndexVar + " = ")
+	content.Write(indexJSON)
+	content.WriteString(";\n")
+	return out.writeFile(path, &content)
+}
@@ -0,0 +1,1006 @@
+// lintian-ssg, a static site generator for lintian tags explanations.
+//
+// Copyright (C) Nicolas Peugnet <nicolas@club1.fr>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package ssg generates a static website for the explanations of the lintian
+// tags.
+package ssg
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/n-peugnet/lintian-ssg/ioutil"
+	"github.com/n-peugnet/lintian-ssg/lintian"
+	"github.com/n-peugnet/lintian-ssg/markdown"
+	"github.com/n-peugnet/lintian-ssg/markdown/goldmark_ext"
+	"github.com/n-peugnet/lintian-ssg/output"
+	"github.com/n-peugnet/lintian-ssg/search"
+	"github.com/n-peugnet/lintian-ssg/version"
+)
+
+const (
+	// DefaultManualPath is the path of the lintian manual installed by the
+	// lintian package.
+	DefaultManualPath = "/usr/share/doc/lintian/lintian.html"
+	// DefaultOutDir is the default output directory.
+	DefaultOutDir = "out"
+
+	sourceURLFmt = "https://salsa.debian.org/lintian/lintian/-/blob/%s/tags/%s.tag"
+)
+
+type tmplParams struct {
+	DateYear       int
+	DateHuman      string
+	DateMachine    string
+	BaseURL        string
+	Root           string
+	Version        string
+	VersionLintian string
+	FooterHTML     template.HTML
+	FeedURL        string // absolute URL of the Atom feed, if any
+	LocalDebianCSS bool   // whether debian.css is included in the website
+	// Versions lists the directories of the versions of the website, if it
+	// has been built for multiple lintian versions, SiteVersion being the
+	// current one.
+	Versions    []string
+	SiteVersion string
+}
+
+type indexTmplParams struct {
+	tmplParams
+	Tags         []*lintian.Tag
+	Levels       []levelCount
+	Experimental int
+	PrevVersion  string // previous lintian version, if the changes since it are available
+}
+
+type manualTmplParams struct {
+	tmplParams
+	Manual template.HTML
+}
+
+type tagTmplParams struct {
+	tmplParams
+	*lintian.Tag
+	PrevName     string
+	ReferencedBy []string
+	// The following fields shadow the methods of lintian.Tag, to render the
+	// Markdown in the context of the build.
+	ExplanationHTML template.HTML
+	SeeAlsoHTML     []template.HTML
+	Screens         []screenTmplParams
+}
+
+type screenTmplParams struct {
+	*lintian.Screen
+	ReasonHTML  template.HTML
+	SeeAlsoHTML template.HTML
+}
+
+// renderMarkdown renders all the Markdown fields of the tag for the given page,
+// in which the names of the other tags are linked to their page.
+func (p *tagTmplParams) renderMarkdown(md *markdown.Renderer, page markdown.Page) {
+	p.ExplanationHTML = md.ToHTML(p.Explanation, markdown.StyleFull, page)
+	p.SeeAlsoHTML = make([]template.HTML, len(p.Tag.SeeAlso))
+	for i, str := range p.Tag.SeeAlso {
+		p.SeeAlsoHTML[i] = md.ToHTML(str, markdown.StyleInline, page)
+	}
+	p.Screens = make([]screenTmplParams, len(p.Tag.Screens))
+	for i := range p.Tag.Screens {
+		screen := &p.Tag.Screens[i]
+		p.Screens[i] = screenTmplParams{
+			Screen:      screen,
+			ReasonHTML:  md.ToHTML(screen.Reason, markdown.StyleFull, page),
+			SeeAlsoHTML: md.ToHTML("See also: "+strings.Join(screen.SeeAlso, ", "), markdown.StyleInline, page),
+		}
+	}
+}
+
+var (
+	//go:embed templates/index.html.tmpl
+	indexTmplStr string
+	//go:embed templates/tag.html.tmpl
+	tagTmplStr string
+	//go:embed templates/renamed.html.tmpl
+	renamedTmplStr string
+	//go:embed templates/manual.html.tmpl
+	manualTmplStr string
+	//go:embed templates/check.html.tmpl
+	checkTmplStr string
+	//go:embed templates/checks.html.tmpl
+	checksTmplStr string
+	//go:embed templates/list.html.tmpl
+	listTmplStr string
+	//go:embed templates/search.html.tmpl
+	searchTmplStr string
+	//go:embed templates/references.html.tmpl
+	refsTmplStr string
+	//go:embed templates/about.html.tmpl
+	aboutTmplStr string
+	//go:embed templates/404.html.tmpl
+	e404TmplStr string
+	//go:embed templates/changes.html.tmpl
+	changesTmplStr string
+	//go:embed templates/absent.html.tmpl
+	absentTmplStr string
+	//go:embed templates/redirect.html.tmpl
+	redirectTmplStr string
+	//go:embed assets/main.css
+	mainCSS []byte
+	//go:embed assets/openlogo-50.svg
+	logoSVG []byte
+	//go:embed assets/favicon.ico
+	faviconICO []byte
+)
+
+// site is a directory of the output, in which a version of the website is
+// written.
+type site struct {
+	prefix string        // path of the directory relative to the root of the website
+	pages  chan<- string // pages to add to the sitemap
+	files  output.Writer // destination of the files of the website
+}
+
+func (s *site) writeFile(path string, r io.Reader) error {
+	return s.files.WriteFile(s.prefix+path, r)
+}
+
+// sub returns the site located in the directory dir of s.
+func (s *site) sub(dir string) *site {
+	return &site{
+		prefix: s.prefix + dir + "/",
+		pages:  s.pages,
+		files:  s.files,
+	}
+}
+
+// addPage adds the page located at path in the site to the sitemap.
+func (s *site) addPage(path string) {
+	if s.pages != nil {
+		s.pages <- s.prefix + path
+	}
+}
+
+// keep keeps the page located at path from the previous build, as it would be
+// rendered with the same content, and adds it to the sitemap.
+func (s *site) keep(path string) {
+	s.addPage(path)
+	if files, ok := s.files.(*manifest); ok {
+		files.Keep(s.prefix + path)
+	}
+}
+
+// unlisted returns a copy of the site whose pages are not added to the sitemap.
+func (s site) unlisted() *site {
+	s.pages = nil
+	return &s
+}
+
+// templates holds all the parsed templates of the website.
+type templates struct {
+	index    *template.Template
+	tag      *template.Template
+	renamed  *template.Template
+	absent   *template.Template
+	changes  *template.Template
+	manual   *template.Template
+	check    *template.Template
+	checks   *template.Template
+	list     *template.Template
+	search   *template.Template
+	refs     *template.Template
+	about    *template.Template
+	e404     *template.Template
+	redirect *template.Template
+}
+
+// readTemplate returns the content of the template file named name in
+// overrides, or the embedded one if there is none.
+func readTemplate(overrides fs.FS, name string, embedded string) (string, error) {
+	if overrides == nil {
+		return embedded, nil
+	}
+	content, err := fs.ReadFile(overrides, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return embedded, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// parseTemplate parses the template file named name, which extends layout if
+// it is not nil. The blocks it defines are named after the file in the errors.
+func parseTemplate(overrides fs.FS, layout *template.Template, name string, embedded string) (*template.Template, error) {
+	content, err := readTemplate(overrides, name, embedded)
+	if err != nil {
+		return nil, err
+	}
+	if layout == nil {
+		tmpl, err := template.New(name).Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return tmpl, nil
+	}
+	tmpl, err := layout.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if _, err := tmpl.New(name).Parse(content); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// parsePartials parses the templates of overrides that do not replace one of
+// the known templates into layout, each of them defining the block named
+// after it, such as "head-extra" for "head-extra.html.tmpl". The block must
+// exist in layout.
+func parsePartials(overrides fs.FS, layout *template.Template, known map[string]bool) error {
+	if overrides == nil {
+		return nil
+	}
+	names, err := fs.Glob(overrides, "*.html.tmpl")
+	if err != nil {
+		return err
+	}
+	blocks := make(map[string]bool)
+	for _, tmpl := range layout.Templates() {
+		if tmpl.Name() != layout.Name() {
+			blocks[tmpl.Name()] = true
+		}
+	}
+	for _, name := range names {
+		if known[name] {
+			continue
+		}
+		block := strings.TrimSuffix(name, ".html.tmpl")
+		if !blocks[block] {
+			valid := make([]string, 0, len(blocks))
+			for block := range blocks {
+				valid = append(valid, block)
+			}
+			sort.Strings(valid)
+			return fmt.Errorf("%s: no block %q in the layout, valid blocks are: %s", name, block, strings.Join(valid, ", "))
+		}
+		content, err := fs.ReadFile(overrides, name)
+		if err != nil {
+			return err
+		}
+		if _, err := layout.New(block).Parse(string(content)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkTemplate executes tmpl with the sample data, to report the errors,
+// such as the fields that do not exist, before any page is rendered. The
+// branches that the sample data does not reach are only checked when
+// rendering the pages.
+func checkTemplate(tmpl *template.Template, sample any) error {
+	return tmpl.Execute(io.Discard, sample)
+}
+
+// parseTemplates parses all the templates of the website, the ones in
+// overrides replacing the embedded templates of the same name, and the other
+// ones being parsed as partials of the layout. The templates are then checked
+// against sample data.
+func parseTemplates(overrides fs.FS) (*templates, error) {
+	tmpls := &templates{}
+	sampleTag := &lintian.Tag{Name: "sample-tag", Check: "sample"}
+	pages := []struct {
+		tmpl     **template.Template
+		name     string
+		embedded string
+		sample   any
+	}{
+		{&tmpls.tag, "tag.html.tmpl", tagTmplStr, &tagTmplParams{Tag: sampleTag}},
+		{&tmpls.renamed, "renamed.html.tmpl", renamedTmplStr, &tagTmplParams{Tag: sampleTag}},
+		{&tmpls.absent, "absent.html.tmpl", absentTmplStr, &absentTmplParams{}},
+		{&tmpls.changes, "changes.html.tmpl", changesTmplStr, &changesTmplParams{Changes: &lintian.Changes{}}},
+		{&tmpls.manual, "manual.html.tmpl", manualTmplStr, &manualTmplParams{}},
+		{&tmpls.check, "check.html.tmpl", checkTmplStr, &checkTmplParams{}},
+		{&tmpls.checks, "checks.html.tmpl", checksTmplStr, &checksTmplParams{}},
+		{&tmpls.list, "list.html.tmpl", listTmplStr, &listTmplParams{}},
+		{&tmpls.search, "search.html.tmpl", searchTmplStr, tmplParams{}},
+		{&tmpls.refs, "references.html.tmpl", refsTmplStr, &refsTmplParams{}},
+		{&tmpls.about, "about.html.tmpl", aboutTmplStr, tmplParams{}},
+		{&tmpls.e404, "404.html.tmpl", e404TmplStr, tmplParams{}},
+	}
+	known := map[string]bool{"index.html.tmpl": true, "redirect.html.tmpl": true}
+	for _, page := range pages {
+		known[page.name] = true
+	}
+	var err error
+	if tmpls.index, err = parseTemplate(overrides, nil, "index.html.tmpl", indexTmplStr); err != nil {
+		return nil, err
+	}
+	if err := parsePartials(overrides, tmpls.index, known); err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		if *page.tmpl, err = parseTemplate(overrides, tmpls.index, page.name, page.embedded); err != nil {
+			return nil, err
+		}
+	}
+	if tmpls.redirect, err = parseTemplate(overrides, nil, "redirect.html.tmpl", redirectTmplStr); err != nil {
+		return nil, err
+	}
+	// The templates cannot be cloned anymore once executed.
+	for _, page := range pages {
+		if err := checkTemplate(*page.tmpl, page.sample); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkTemplate(tmpls.index, indexTmplParams{}); err != nil {
+		return nil, err
+	}
+	if err := checkTemplate(tmpls.redirect, &redirectTmplParams{}); err != nil {
+		return nil, err
+	}
+	return tmpls, nil
+}
+
+func rootRelPath(dir string) string {
+	count := strings.Count(dir, "/")
+	if count == 0 {
+		return "./"
+	}
+	return strings.Repeat("../", count)
+}
+
+// openInput returns the tags source located at path, which can either be a
+// lintian source tree or a JSON file.
+func openInput(path string) (lintian.Source, error) {
+	if path != "-" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return lintian.OpenSourceTree(path)
+		}
+	}
+	return lintian.OpenJSONFile(path)
+}
+
+// tagPage returns the path of the page of the tag named name.
+func tagPage(name string) string {
+	return path.Join("tags", name+".html")
+}
+
+// renderTag writes the page of the tag, and the ones of its previous names
+// which redirect to it, unless ctx is cancelled.
+func renderTag(ctx context.Context, tag *lintian.Tag, refs *lintian.References, md *markdown.Renderer, extRefs *refIndex, params *tmplParams, tmpls *templates, out *site) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	page := tagPage(tag.Name)
+	tagParams := tagTmplParams{
+		tmplParams:   *params,
+		Tag:          tag,
+		ReferencedBy: refs.ReferencedBy(tag.Name),
+	}
+	tagParams.Root = rootRelPath(page)
+	tagExtRefs := goldmark_ext.References{}
+	tagParams.renderMarkdown(md, markdown.Page{Root: tagParams.Root, Tag: tag.Name, References: &tagExtRefs})
+	extRefs.add(tag.Name, tagExtRefs)
+	if err := writeSimplePage(tmpls.tag, &tagParams, page, out); err != nil {
+		return err
+	}
+	for _, name := range tag.RenamedFrom {
+		page := tagPage(name)
+		tagParams.Root = rootRelPath(page)
+		tagParams.PrevName = name
+		if err := writeSimplePage(tmpls.renamed, &tagParams, page, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepTag keeps the pages of the tag from the previous build.
+func keepTag(tag *lintian.Tag, out *site) {
+	out.keep(tagPage(tag.Name))
+	for _, name := range tag.RenamedFrom {
+		out.keep(tagPage(name))
+	}
+}
+
+// copyAssets copies the files of assets in out, adding the HTML ones to the
+// sitemap, and returns their names.
+func copyAssets(assets fs.FS, out *site) (map[string]bool, error) {
+	copied := make(map[string]bool)
+	if assets == nil {
+		return copied, nil
+	}
+	err := fs.WalkDir(assets, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return inputError("read assets", err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		content, err := fs.ReadFile(assets, name)
+		if err != nil {
+			return inputError("read assets", err)
+		}
+		if path.Ext(name) == ".html" {
+			out.addPage(name)
+		}
+		copied[name] = true
+		return out.writeFile(name, bytes.NewReader(content))
+	})
+	return copied, err
+}
+
+// readDebianCSS returns the content of the Debian stylesheet located at path.
+func readDebianCSS(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, inputError("read debian.css", err)
+	}
+	return content, nil
+}
+
+// writeAssets writes the embedded assets in out, along with the ones of
+// assets, which replace the embedded ones of the same name, and the Debian
+// stylesheet located at debianCSSPath, if any.
+func writeAssets(assets fs.FS, debianCSSPath string, out *site) error {
+	copied, err := copyAssets(assets, out)
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		"main.css":        mainCSS,
+		"openlogo-50.svg": logoSVG,
+		"favicon.ico":     faviconICO,
+	}
+	if debianCSSPath != "" {
+		if files["debian.css"], err = readDebianCSS(debianCSSPath); err != nil {
+			return err
+		}
+	}
+	for name, content := range files {
+		if copied[name] {
+			continue
+		}
+		if err := out.writeFile(name, bytes.NewReader(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSitemap(baseURL string, pages []string, out *site) error {
+	sort.Strings(pages)
+	builder := strings.Builder{}
+	builder.Grow(len(pages) * 32)
+	for _, page := range pages {
+		builder.WriteString(baseURL + page + "\n")
+	}
+	return out.writeFile("sitemap.txt", strings.NewReader(builder.String()))
+}
+
+func writeManual(tmpl *template.Template, params *tmplParams, manualPath string, path string, out *site) error {
+	file, err := os.Open(manualPath)
+	if err != nil {
+		return inputError("read manual", err)
+	}
+	defer file.Close()
+	reader := ioutil.NewBodyFilterReader(file)
+	body := bytes.Buffer{}
+	if _, err := io.Copy(&body, reader); err != nil {
+		return inputError("read manual", err)
+	}
+	out.addPage(path)
+	manualParams := manualTmplParams{*params, template.HTML(body.String())}
+	manualParams.Root = rootRelPath(path)
+	content := bytes.Buffer{}
+	if err := tmpl.Execute(&content, &manualParams); err != nil {
+		return inputError("execute template", err)
+	}
+	return out.writeFile(path, &content)
+}
+
+func writeSimplePage(tmpl *template.Template, params any, path string, out *site) error {
+	content := bytes.Buffer{}
+	if err := tmpl.Execute(&content, params); err != nil {
+		return inputError("execute template", err)
+	}
+	out.addPage(path)
+	return out.writeFile(path, &content)
+}
+
+// handlePages collects the pages sent to the sitemap until the channel is
+// closed, writes the sitemap and returns the number of pages.
+func (g *Generator) handlePages(pages <-chan string, out *site) (int, error) {
+	s := make([]string, 0, 2048)
+	for page := range pages {
+		s = append(s, page)
+	}
+	if g.opts.BaseURL != "" && !g.opts.NoSitemap {
+		if err := writeSitemap(g.opts.BaseURL, s, out); err != nil {
+			return len(s), err
+		}
+	}
+	return len(s), nil
+}
+
+// versionParams returns the params of the pages of the website for the given
+// set of tags, written in out.
+func versionParams(params tmplParams, set *tagSet, out *site) tmplParams {
+	params.VersionLintian = set.version
+	params.SiteVersion = strings.TrimSuffix(out.prefix, "/")
+	if params.BaseURL != "" {
+		params.BaseURL += out.prefix
+	}
+	return params
+}
+
+func withRoot(params tmplParams, root string) tmplParams {
+	params.Root = root
+	return params
+}
+
+// writeVersion writes the website for the given set of tags in out. The tags
+// of the other sets that are absent from this one get a page listing the
+// versions in which they are available, and the changes since the previous
+// version are listed. If set is dirty, only the pages of its dirty tags are
+// rendered, the other ones being kept from the previous build, along with
+// their references to external resources. The pages are written concurrently by at most
+// Options.Jobs workers, and the first error cancels the writing of the
+// remaining ones.
+func (g *Generator) writeVersion(ctx context.Context, params tmplParams, set *tagSet, sets []*tagSet, out *site) error {
+	params = versionParams(params, set, out)
+
+	tagList := make([]string, 0, len(set.tags))
+	checks := make(map[string][]*lintian.Tag)
+	searchIndex := search.NewIndex()
+	for _, tag := range set.tags {
+		tagList = append(tagList, tag.Name)
+		indexTag(searchIndex, tag)
+		if tag.Check != "" {
+			checks[tag.Check] = append(checks[tag.Check], tag)
+		}
+	}
+
+	refs := lintian.NewReferences(set.tags)
+	md := markdown.NewRenderer(markdown.Options{
+		Tags:      refs,
+		PolicyURL: g.opts.PolicyURL,
+		DevrefURL: g.opts.DevrefURL,
+	})
+	extRefs, ok := g.extRefs[out.prefix]
+	render := set.dirty
+	if !ok || render == nil {
+		extRefs, render = newRefIndex(), nil
+		g.extRefs[out.prefix] = extRefs
+	}
+	for name := range render {
+		extRefs.remove(name)
+	}
+	workers, ctx := newGroup(ctx)
+	workers.setLimit(g.opts.Jobs)
+	workers.run(func() error {
+		tagListJSON, err := json.Marshal(tagList)
+		if err != nil {
+			return outputError("marshal tagList", err)
+		}
+		if err := out.writeFile("taglist.json", bytes.NewReader(tagListJSON)); err != nil {
+			return outputError("write taglist", err)
+		}
+		if err := writeAssets(g.opts.Assets, g.opts.DebianCSS, out); err != nil {
+			return outputError("write assets", err)
+		}
+		if err := writeSearchIndex(searchIndex, "search-index.js", out); err != nil {
+			return outputError("write search index", err)
+		}
+		if err := writeSimplePage(g.tmpls.search, withRoot(params, "./"), "search.html", out.unlisted()); err != nil {
+			return outputError("write search.html", err)
+		}
+		if err := writeManual(g.tmpls.manual, &params, g.opts.ManualPath, "manual/index.html", out); err != nil {
+			return outputError("write manual", err)
+		}
+		levels, experimental := countLevels(set.tags)
+		indexParams := indexTmplParams{withRoot(params, "./"), set.tags, levels, experimental, ""}
+		if prev := set.previous(sets); prev != nil {
+			if err := writeChanges(g.tmpls.changes, &params, prev, set, out); err != nil {
+				return outputError("write changes", err)
+			}
+			indexParams.PrevVersion = prev.version
+		}
+		if err := writeSimplePage(g.tmpls.index, indexParams, "index.html", out); err != nil {
+			return outputError("write index.html", err)
+		}
+		if err := writeLevels(g.tmpls.list, &params, set.tags, out); err != nil {
+			return outputError("write severities", err)
+		}
+		if err := writeChecks(g.tmpls.check, g.tmpls.checks, &params, checks, out); err != nil {
+			return outputError("write checks", err)
+		}
+		if err := writeSimplePage(g.tmpls.about, withRoot(params, "./"), "about.html", out); err != nil {
+			return outputError("write about.html", err)
+		}
+		if err := writeSimplePage(g.tmpls.e404, withRoot(params, "/"+out.prefix), "404.html", out.unlisted()); err != nil {
+			return outputError("write 404.html", err)
+		}
+		return outputError("write absent tags", writeAbsentTags(g.tmpls.absent, &params, set, sets, out))
+	})
+	for _, tag := range set.tags {
+		if ctx.Err() != nil {
+			break
+		}
+		if render != nil && !render[tag.Name] {
+			keepTag(tag, out)
+			continue
+		}
+		tag := tag
+		workers.run(func() error {
+			return outputError("write tag "+tag.Name, renderTag(ctx, tag, refs, md, extRefs, &params, g.tmpls, out))
+		})
+	}
+
+	if err := workers.wait(); err != nil {
+		return err
+	}
+	return outputError("write references", writeReferences(g.tmpls.refs, &params, extRefs, out))
+}
+
+// Options configures a Generator.
+type Options struct {
+	// Inputs are the paths of JSON files containing the tags, as produced by
+	// "lintian-explain-tags --format=json", or of lintian source trees. The
+	// path "-" designates the standard input.
+	Inputs []string
+	// Sources are read after the Inputs. If there are neither Inputs nor
+	// Sources, the tags are read from "lintian-explain-tags". They can only
+	// be read once, so a Generator with Sources can only be run once: the
+	// next runs and rebuilds fail.
+	Sources []lintian.Source
+	// OutDir is the path of the output directory, DefaultOutDir by default.
+	OutDir string
+	// BaseURL is the URL where the root of the website will be located. It is
+	// used in the sitemap, the feed and the canonical URL of each page.
+	BaseURL string
+	// Footer is added to the footer of each page, inline Markdown elements
+	// being parsed.
+	Footer string
+	// PolicyURL and DevrefURL are the base URLs of the Debian Policy Manual and
+	// Developer's Reference, used to link their sections.
+	PolicyURL string
+	DevrefURL string
+	// ManualPath is the path of the lintian manual, DefaultManualPath by
+	// default.
+	ManualPath string
+	// Templates contains templates overriding the embedded ones of the same
+	// name, such as "tag.html.tmpl". Its other "*.html.tmpl" files replace
+	// the block of the layout of the same name, such as "head-extra".
+	Templates fs.FS
+	// Assets contains files copied in the website, replacing the embedded
+	// assets of the same name, such as "main.css". They are copied in the
+	// directory of each version of the website, and also at its root if it
+	// has been built for multiple lintian versions.
+	Assets fs.FS
+	// DebianCSS is the path of a copy of the Debian stylesheet to include in
+	// the website as debian.css, instead of linking to the one of
+	// www.debian.org.
+	DebianCSS string
+	// Output is the destination of the files of the website. If it is set,
+	// OutDir, Atomic and KeepBuilds are ignored, and the builds are not
+	// incremental. It is not closed by Run.
+	Output output.Writer
+	// NoSitemap disables the generation of the sitemap.
+	NoSitemap bool
+	// Atomic makes the website generated in a new directory, next to the
+	// output one, which is then atomically replaced by a symlink to it.
+	// KeepBuilds previous builds are kept.
+	Atomic     bool
+	KeepBuilds int
+	// Jobs is the maximum number of tags rendered concurrently, the number of
+	// CPUs by default. A negative value removes the limit.
+	Jobs int
+	// Log receives the warnings, log.Default() by default.
+	Log *log.Logger
+}
+
+// Stats are statistics about a run of a Generator.
+type Stats struct {
+	Tags    int // number of tags
+	Pages   int // number of pages
+	Written int // number of files written
+	Skipped int // number of rendered files that were unchanged, and not rewritten
+	Removed int // number of files of the previous build removed
+	// ExplainTags is the state of the exited lintian-explain-tags command, if
+	// it was run.
+	ExplainTags *os.ProcessState
+}
+
+// Generator generates the website.
+type Generator struct {
+	opts  Options
+	tmpls *templates
+	// sets of the last successful build, nil if there is none.
+	sets []*tagSet
+	// sourcesRead is whether Options.Sources have already been consumed.
+	sourcesRead bool
+	// extRefs are the references to external resources of the tags of the
+	// last build, by site prefix.
+	extRefs map[string]*refIndex
+	// Stats of the last run.
+	Stats Stats
+}
+
+// New returns a new Generator configured by opts.
+func New(opts Options) (*Generator, error) {
+	if opts.OutDir == "" {
+		opts.OutDir = DefaultOutDir
+	}
+	if opts.BaseURL != "" && !strings.HasSuffix(opts.BaseURL, "/") {
+		opts.BaseURL += "/"
+	}
+	if opts.PolicyURL == "" {
+		opts.PolicyURL = goldmark_ext.DefaultPolicyURL
+	}
+	if !strings.HasSuffix(opts.PolicyURL, "/") {
+		opts.PolicyURL += "/"
+	}
+	if opts.DevrefURL == "" {
+		opts.DevrefURL = goldmark_ext.DefaultDevrefURL
+	}
+	if !strings.HasSuffix(opts.DevrefURL, "/") {
+		opts.DevrefURL += "/"
+	}
+	if opts.ManualPath == "" {
+		opts.ManualPath = DefaultManualPath
+	}
+	if opts.Jobs == 0 {
+		opts.Jobs = runtime.NumCPU()
+	}
+	if opts.Log == nil {
+		opts.Log = log.Default()
+	}
+	tmpls, err := parseTemplates(opts.Templates)
+	if err != nil {
+		return nil, &buildError{ErrInput, "parse templates", err}
+	}
+	return &Generator{opts: opts, tmpls: tmpls, extRefs: make(map[string]*refIndex)}, nil
+}
+
+// readInput reads the set of tags of the input located at path.
+func (g *Generator) readInput(input string, start time.Time) (*tagSet, error) {
+	source, err := openInput(input)
+	if err != nil {
+		return nil, inputError("open input", err)
+	}
+	set, err := readTags(source)
+	if err != nil {
+		source.Close()
+		return nil, inputError("read tags", err)
+	}
+	if err := source.Close(); err != nil {
+		g.opts.Log.Println("WARNING: close input:", err)
+	}
+	set.input = input
+	if set.date.IsZero() {
+		set.date = inputDate(input, start)
+	}
+	return set, nil
+}
+
+// readInputs reads the sets of tags of all the inputs and sources, or of the
+// output of lintian-explain-tags if there is none.
+func (g *Generator) readInputs(start time.Time) ([]*tagSet, error) {
+	var sets []*tagSet
+	if len(g.opts.Inputs) == 0 && len(g.opts.Sources) == 0 {
+		jsonTagsCmd, err := lintian.StartExplainTags()
+		if err != nil {
+			return nil, inputError("lintian-explain-tags --format=json", err)
+		}
+		set, err := readTags(jsonTagsCmd)
+		if err != nil {
+			jsonTagsCmd.Close()
+			return nil, inputError("read tags", err)
+		}
+		if err := jsonTagsCmd.Close(); err != nil {
+			g.opts.Log.Println("WARNING: lintian-explain-tags --format=json:", err)
+		}
+		g.Stats.ExplainTags = jsonTagsCmd.ProcessState()
+		return append(sets, set), nil
+	}
+	for _, input := range g.opts.Inputs {
+		set, err := g.readInput(input, start)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	if len(g.opts.Sources) != 0 {
+		if g.sourcesRead {
+			return nil, inputError("read tags", errSourcesRead)
+		}
+		g.sourcesRead = true
+	}
+	for i, source := range g.opts.Sources {
+		set, err := readTags(source)
+		if err != nil {
+			return nil, inputError("read tags", err)
+		}
+		set.input = fmt.Sprintf("source %d", i)
+		if set.date.IsZero() {
+			set.date = start
+		}
+		sets = append(sets, set)
+	}
+	if len(sets) > 1 {
+		if err := sortVersions(sets); err != nil {
+			return nil, inputError("multiple inputs", err)
+		}
+	}
+	return sets, nil
+}
+
+// writeSets writes the website for the given sets of tags, sorted from the
+// newest lintian version to the oldest, in root.
+func (g *Generator) writeSets(ctx context.Context, params tmplParams, sets []*tagSet, root *site) error {
+	if len(sets) == 1 {
+		return g.writeVersion(ctx, params, sets[0], nil, root)
+	}
+	params.Versions = []string{latestDir}
+	for _, set := range sets {
+		params.Versions = append(params.Versions, set.version)
+	}
+	if g.opts.BaseURL != "" {
+		if err := writeFeed(g.opts.BaseURL, sets, root.unlisted()); err != nil {
+			return outputError("write feed", err)
+		}
+		params.FeedURL = g.opts.BaseURL + feedPath
+	}
+	for _, set := range sets {
+		if err := g.writeVersion(ctx, params, set, sets, root.sub(set.version)); err != nil {
+			return err
+		}
+	}
+	if err := g.writeVersion(ctx, params, sets[0], sets, root.sub(latestDir)); err != nil {
+		return err
+	}
+	if _, err := copyAssets(g.opts.Assets, root); err != nil {
+		return outputError("write assets", err)
+	}
+	latestParams := versionParams(params, sets[0], root.sub(latestDir))
+	return outputError("write root", writeRoot(g.tmpls.redirect, g.tmpls.e404, &latestParams, root.unlisted()))
+}
+
+// write writes the website for the given sets of tags in root, along with
+// its sitemap.
+func (g *Generator) write(ctx context.Context, params tmplParams, sets []*tagSet, root *site) error {
+	pagesChan := make(chan string, 32)
+	root.pages = pagesChan
+	pages, _ := newGroup(ctx)
+	pages.run(func() error {
+		var err error
+		g.Stats.Pages, err = g.handlePages(pagesChan, root.unlisted())
+		return outputError("write sitemap", err)
+	})
+	err := g.writeSets(ctx, params, sets, root)
+	close(pagesChan)
+	if pagesErr := pages.wait(); err == nil {
+		err = pagesErr
+	}
+	return err
+}
+
+// Run reads the tags and writes the website in the output directory. The
+// first error cancels the build. Errors reading the inputs match ErrInput,
+// and errors writing the website match ErrOutput.
+func (g *Generator) Run(ctx context.Context) error {
+	start := time.Now()
+	g.Stats = Stats{}
+	g.sets = nil
+	sets, err := g.readInputs(start)
+	if err != nil {
+		return err
+	}
+	if err := g.build(ctx, start, sets); err != nil {
+		return err
+	}
+	g.sets = sets
+	return nil
+}
+
+// build writes the website for the given sets of tags, started at start.
+func (g *Generator) build(ctx context.Context, start time.Time, sets []*tagSet) error {
+	g.Stats.Tags = 0
+	for _, set := range sets {
+		g.Stats.Tags += len(set.tags)
+	}
+
+	date := start.UTC()
+	params := tmplParams{
+		BaseURL:        g.opts.BaseURL,
+		DateYear:       date.Year(),
+		DateHuman:      date.Format(time.RFC1123),
+		DateMachine:    date.Format(time.RFC3339),
+		Version:        version.Number,
+		FooterHTML:     markdown.ToHTML(g.opts.Footer, markdown.StyleInline),
+		LocalDebianCSS: g.opts.DebianCSS != "",
+	}
+
+	root := &site{files: g.opts.Output}
+	if root.files != nil {
+		return g.write(ctx, params, sets, root)
+	}
+
+	outDir, baseDir := g.opts.OutDir, g.opts.OutDir
+	var build *atomicBuild
+	var err error
+	if g.opts.Atomic {
+		build, err = newAtomicBuild(g.opts.OutDir, date)
+		if err != nil {
+			return outputError("create build dir", err)
+		}
+		defer func() {
+			if build != nil {
+				build.abort()
+			}
+		}()
+		outDir, baseDir = build.dir, build.prev
+	} else if err := os.MkdirAll(g.opts.OutDir, 0755); err != nil {
+		return outputError("create out dir", err)
+	}
+	files, err := loadManifest(outDir, baseDir, params.DateHuman, params.DateMachine)
+	if err != nil {
+		return outputError("load manifest", err)
+	}
+
+	root.files = files
+	if err := g.write(ctx, params, sets, root); err != nil {
+		return err
+	}
+	if err := files.Close(); err != nil {
+		return outputError("write manifest", err)
+	}
+	g.Stats.Written, g.Stats.Skipped, g.Stats.Removed = files.written, files.skipped, files.removed
+	if build != nil {
+		if err := build.publish(); err != nil {
+			return outputError("publish build", err)
+		}
+		published := build
+		build = nil
+		if err := published.prune(g.opts.KeepBuilds); err != nil {
+			return outputError("remove previous builds", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+	"github.com/n-peugnet/lintian-ssg/ssg"
+)
+
+const tagsJSON = `[{"name":"test-tag","visibility":"info","explanation":"This is a test.","lintian_version":"2.118.0"}]`
+
+// options returns the options of a Generator that reads the tags from
+// tagsJSON and writes the website in a temporary directory.
+func options(t *testing.T) ssg.Options {
+	dir := t.TempDir()
+	manualPath := filepath.Join(dir, "manual.html")
+	if err := os.WriteFile(manualPath, []byte("<body>\nMANUAL\n</body>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ssg.Options{
+		Sources:    []lintian.Source{lintian.NewJSONSource(strings.NewReader(tagsJSON))},
+		OutDir:     filepath.Join(dir, "out"),
+		ManualPath: manualPath,
+	}
+}
+
+func assertFileContains(t *testing.T, path string, needle string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(content, []byte(needle)) {
+		t.Errorf("expected %q to be in %s, actual:\n%s", needle, path, content)
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	opts := options(t)
+	opts.Templates = fstest.MapFS{
+		"about.html.tmpl": {Data: []byte(`{{ define "content" }}CUSTOM ABOUT{{ end }}`)},
+	}
+	generator, err := ssg.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContains(t, filepath.Join(opts.OutDir, "tags", "test-tag.html"), "<p>This is a test.</p>")
+	assertFileContains(t, filepath.Join(opts.OutDir, "about.html"), "CUSTOM ABOUT")
+	if generator.Stats.Tags != 1 {
+		t.Errorf("expected 1 tag, got: %d", generator.Stats.Tags)
+	}
+}
+
+func TestGeneratorTemplateError(t *testing.T) {
+	opts := options(t)
+	opts.Templates = fstest.MapFS{
+		"tag.html.tmpl": {Data: []byte(`{{ define "content" }}{{ end `)},
+	}
+	_, err := ssg.New(opts)
+	if !errors.Is(err, ssg.ErrInput) {
+		t.Fatal("expected input error, got:", err)
+	}
+	if !strings.Contains(err.Error(), "tag.html.tmpl") {
+		t.Errorf("expected error to contain the name of the template: %v", err)
+	}
+}
+
+func TestGeneratorOutputError(t *testing.T) {
+	opts := options(t)
+	if err := os.WriteFile(opts.OutDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	generator, err := ssg.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.Run(context.Background()); !errors.Is(err, ssg.ErrOutput) {
+		t.Fatal("expected output error, got:", err)
+	}
+}
+
+func TestGeneratorSourcesRead(t *testing.T) {
+	generator, err := ssg.New(options(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err = generator.Run(context.Background())
+	if !errors.Is(err, ssg.ErrInput) {
+		t.Fatal("expected input error, got:", err)
+	}
+	if !strings.Contains(err.Error(), "already been read") {
+		t.Errorf("expected error to tell that the sources were read: %v", err)
+	}
+}
+
+func TestGeneratorRebuild(t *testing.T) {
+	opts := options(t)
+	dir := filepath.Dir(opts.OutDir)
+	input := filepath.Join(dir, "tags.json")
+	write := func(path string, content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(input, `[
+		{"name":"test-tag","visibility":"info","explanation":"This is a test.","lintian_version":"2.118.0"},
+		{"name":"other-tag","visibility":"info","explanation":"This is another test.","lintian_version":"2.118.0"}
+	]`)
+	tmplDir := filepath.Join(dir, "templates")
+	if err := os.Mkdir(tmplDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	opts.Sources = nil
+	opts.Inputs = []string{input}
+	opts.Templates = os.DirFS(tmplDir)
+	assetsDir := filepath.Join(dir, "assets")
+	if err := os.Mkdir(assetsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join(assetsDir, "robots.txt"), "User-agent: *")
+	opts.Assets = os.DirFS(assetsDir)
+	generator, err := ssg.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	full := generator.Stats.Written
+
+	write(input, `[
+		{"name":"test-tag","visibility":"info","explanation":"This is an updated test.","lintian_version":"2.118.0"},
+		{"name":"other-tag","visibility":"info","explanation":"This is another test.","lintian_version":"2.118.0"}
+	]`)
+	if err := generator.Rebuild(context.Background(), []string{input}); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContains(t, filepath.Join(opts.OutDir, "tags", "test-tag.html"), "<p>This is an updated test.</p>")
+	if stats := generator.Stats; stats.Written+stats.Skipped != full-1 {
+		t.Errorf("expected only the page of other-tag not to be rendered, got: %+v", stats)
+	}
+
+	write(opts.ManualPath, "<body>\nUPDATED MANUAL\n</body>\n")
+	write(filepath.Join(tmplDir, "about.html.tmpl"), `{{ define "content" }}CUSTOM ABOUT{{ end }}`)
+	paths := []string{opts.ManualPath, filepath.Join(tmplDir, "about.html.tmpl")}
+	if err := generator.Rebuild(context.Background(), paths); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContains(t, filepath.Join(opts.OutDir, "manual", "index.html"), "UPDATED MANUAL")
+	assertFileContains(t, filepath.Join(opts.OutDir, "about.html"), "CUSTOM ABOUT")
+	if stats := generator.Stats; stats.Written+stats.Skipped != full-2 {
+		t.Errorf("expected the pages of the tags not to be rendered, got: %+v", stats)
+	}
+
+	if err := os.Remove(filepath.Join(assetsDir, "robots.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.Rebuild(context.Background(), []string{filepath.Join(assetsDir, "robots.txt")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(opts.OutDir, "robots.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the removed asset to be removed, got: %v", err)
+	}
+	assertFileContains(t, filepath.Join(opts.OutDir, "tags", "other-tag.html"), "<p>This is another test.</p>")
+
+	write(input, `[
+		{"name":"test-tag","visibility":"info","explanation":"This is an updated test.","lintian_version":"2.118.0"}
+	]`)
+	if err := generator.Rebuild(context.Background(), []string{input}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(opts.OutDir, "tags", "other-tag.html")); !os.IsNotExist(err) {
+		t.Errorf("expected the page of the removed tag to be removed, got: %v", err)
+	}
+}
+
+func TestGeneratorPartials(t *testing.T) {
+	opts := options(t)
+	opts.Templates = fstest.MapFS{
+		"head-extra.html.tmpl":   {Data: []byte(`<meta name="custom" content="{{ .Version }}">`)},
+		"footer-extra.html.tmpl": {Data: []byte(`<p>CUSTOM FOOTER</p>`)},
+	}
+	generator, err := ssg.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, page := range []string{"index.html", "about.html", filepath.Join("tags", "test-tag.html")} {
+		assertFileContains(t, filepath.Join(opts.OutDir, page), `<meta name="custom" content="`)
+		assertFileContains(t, filepath.Join(opts.OutDir, page), "<p>CUSTOM FOOTER</p>")
+	}
+}
+
+func TestGeneratorUnknownPartial(t *testing.T) {
+	opts := options(t)
+	opts.Templates = fstest.MapFS{
+		"header-extra.html.tmpl": {Data: []byte(`<p>CUSTOM HEADER</p>`)},
+	}
+	_, err := ssg.New(opts)
+	if !errors.Is(err, ssg.ErrInput) {
+		t.Fatal("expected input error, got:", err)
+	}
+	for _, expected := range []string{"header-extra.html.tmpl", "head-extra", "footer-extra"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q: %v", expected, err)
+		}
+	}
+}
+
+func TestGeneratorTemplateExecError(t *testing.T) {
+	opts := options(t)
+	opts.Templates = fstest.MapFS{
+		"about.html.tmpl": {Data: []byte(`{{ define "content" }}{{ index .Versions 3 }}{{ end }}`)},
+	}
+	_, err := ssg.New(opts)
+	if !errors.Is(err, ssg.ErrInput) {
+		t.Fatal("expected input error, got:", err)
+	}
+	if !strings.Contains(err.Error(), "about.html.tmpl") {
+		t.Errorf("expected error to contain the name of the template: %v", err)
+	}
+}
+
+func TestGeneratorTemplateMissingField(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+	}{
+		{"tag.html.tmpl", `{{ define "content" }}{{ .Name }}{{ .Missing }}{{ end }}`},
+		{"head-extra.html.tmpl", `{{ .Missing }}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			opts := options(t)
+			opts.Templates = fstest.MapFS{c.name: {Data: []byte(c.template)}}
+			_, err := ssg.New(opts)
+			if !errors.Is(err, ssg.ErrInput) {
+				t.Fatal("expected input error, got:", err)
+			}
+			name := strings.TrimSuffix(c.name, ".html.tmpl")
+			if !strings.Contains(err.Error(), name) || !strings.Contains(err.Error(), "Missing") {
+				t.Errorf("expected error to contain the name of the template and the field: %v", err)
+			}
+		})
+	}
+}
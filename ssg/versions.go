@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssg
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/n-peugnet/lintian-ssg/lintian"
+)
+
+// latestDir is the directory in which the newest version of the website is
+// written, when it is built for multiple lintian versions.
+const latestDir = "latest"
+
+// tagSet is the set of tags of a lintian version.
+type tagSet struct {
+	input   string
+	version string
+	date    time.Time // date of the lintian version
+	tags    []*lintian.Tag
+	names   map[string]bool // names of the tags, including their previous names
+	// dirty are the names of the tags whose pages must be rendered again by
+	// a rebuild, or nil if all of them must be.
+	dirty map[string]bool
+}
+
+type absentTmplParams struct {
+	tmplParams
+	Name        string
+	AvailableIn []string
+}
+
+type redirectTmplParams struct {
+	BaseURL string
+	URL     string
+}
+
+// readTags reads all the tags of the source. The version of the set is the
+// lintian version of its first tag, and its date the one of the source, if
+// known.
+func readTags(source lintian.Source) (*tagSet, error) {
+	set := &tagSet{
+		tags:  make([]*lintian.Tag, 0, 2048),
+		names: make(map[string]bool, 2048),
+	}
+	if dated, ok := source.(interface{ Date() time.Time }); ok {
+		set.date = dated.Date()
+	}
+	for {
+		tag, err := source.Next()
+		if err == io.EOF {
+			return set, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if set.version == "" {
+			set.version = tag.LintianVersion
+		}
+		set.tags = append(set.tags, tag)
+		set.names[tag.Name] = true
+		for _, name := range tag.RenamedFrom {
+			set.names[name] = true
+		}
+	}
+}
+
+// inputDate returns the modification time of the input file, used as the date
+// of its lintian version when the source does not provide it. The fallback is
+// returned for the standard input.
+func inputDate(input string, fallback time.Time) time.Time {
+	if input == "-" {
+		return fallback
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return fallback
+	}
+	return info.ModTime()
+}
+
+// previous returns the set preceding set in the sorted sets, which is the one
+// of the previous lintian version, or nil if there is none.
+func (set *tagSet) previous(sets []*tagSet) *tagSet {
+	for i, other := range sets {
+		if other == set && i+1 < len(sets) {
+			return sets[i+1]
+		}
+	}
+	return nil
+}
+
+// sortVersions sorts the sets from the newest lintian version to the oldest,
+// and checks that each of them has a distinct version.
+func sortVersions(sets []*tagSet) error {
+	sort.SliceStable(sets, func(i, j int) bool {
+		return lintian.CompareVersions(sets[i].version, sets[j].version) > 0
+	})
+	for i, set := range sets {
+		if set.version == "" {
+			return fmt.Errorf("%s: unknown lintian version", set.input)
+		}
+		if i > 0 && lintian.CompareVersions(set.version, sets[i-1].version) == 0 {
+			return fmt.Errorf("%s: duplicate lintian version %s", set.input, set.version)
+		}
+	}
+	return nil
+}
+
+// writeAbsentTags writes a page for each tag of the other sets that is absent
+// from set, listing the versions in which it is available.
+func writeAbsentTags(tmpl *template.Template, params *tmplParams, set *tagSet, sets []*tagSet, out *site) error {
+	availableIn := make(map[string][]string)
+	for _, other := range sets {
+		for name := range other.names {
+			if !set.names[name] {
+				availableIn[name] = append(availableIn[name], other.version)
+			}
+		}
+	}
+	for name, versions := range availableIn {
+		page := tagPage(name)
+		absentParams := absentTmplParams{
+			tmplParams:  withRoot(*params, rootRelPath(page)),
+			Name:        name,
+			AvailableIn: versions,
+		}
+		if err := writeSimplePage(tmpl, &absentParams, page, out.unlisted()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRoot writes the pages at the root of a website built for multiple
+// lintian versions: an index redirecting to the latest version and a copy of
+// its 404 page.
+func writeRoot(redirectTmpl *template.Template, e404Tmpl *template.Template, params *tmplParams, root *site) error {
+	redirectParams := redirectTmplParams{params.BaseURL, latestDir + "/index.html"}
+	if err := writeSimplePage(redirectTmpl, &redirectParams, "index.html", root); err != nil {
+		return err
+	}
+	return writeSimplePage(e404Tmpl, withRoot(*params, "/"+latestDir+"/"), "404.html", root)
+}
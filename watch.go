@@ -0,0 +1,77 @@
+// lintian-ssg, a static site generator for lintian tags explanations.
+//
+// Copyright (C) Nicolas Peugnet <nicolas@club1.fr>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/n-peugnet/lintian-ssg/ssg"
+	"github.com/n-peugnet/lintian-ssg/watch"
+)
+
+// rebuildDelay is the time to wait after a change before rebuilding the
+// website, so that the changes made at once are handled together.
+const rebuildDelay = 100 * time.Millisecond
+
+// watchPaths returns the channel on which the changes of the watched paths
+// are sent, and the function to stop watching them.
+func watchPaths(watched []string) (<-chan string, func(), error) {
+	watcher, err := watch.New()
+	if err != nil {
+		return nil, nil, fmt.Errorf("watch: %w", err)
+	}
+	for _, path := range watched {
+		if err := watcher.Add(path); err != nil {
+			watcher.Close()
+			return nil, nil, fmt.Errorf("%w: watch: %v", ssg.ErrInput, err)
+		}
+	}
+	return watcher.Events(), func() { watcher.Close() }, nil
+}
+
+// watchSite rebuilds the website generated by the last run of generator when
+// one of the watched paths changes, until ctx is done. The errors of the
+// rebuilds are logged and do not stop watching.
+func watchSite(ctx context.Context, generator *ssg.Generator, watched []string, logger *log.Logger) error {
+	events, stopWatching, err := watchPaths(watched)
+	if err != nil {
+		return err
+	}
+	defer stopWatching()
+	go func() {
+		<-ctx.Done()
+		stopWatching()
+	}()
+	logger.Println("watching for changes")
+	for paths := watch.Batch(events, rebuildDelay); paths != nil; paths = watch.Batch(events, rebuildDelay) {
+		if err := generator.Rebuild(ctx, paths); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			logger.Println("ERROR: rebuild:", err)
+			continue
+		}
+		stats := &generator.Stats
+		logger.Printf("rebuilt after changes of %v: %d files written, %d skipped, %d removed",
+			paths, stats.Written, stats.Skipped, stats.Removed)
+	}
+	return nil
+}
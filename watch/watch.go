@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package watch notifies the changes of files and directory trees.
+package watch
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnsupported is returned by New on the platforms where the changes of the
+// files cannot be watched.
+var ErrUnsupported = errors.New("watching files is not supported on this platform")
+
+// Batch returns the paths received from events, once no new path has been
+// received for the given delay, so that the multiple events caused by a
+// single save are handled together. It returns nil if events is closed.
+func Batch(events <-chan string, delay time.Duration) []string {
+	path, ok := <-events
+	if !ok {
+		return nil
+	}
+	paths := []string{path}
+	seen := map[string]bool{path: true}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case path, ok := <-events:
+			if !ok {
+				return paths
+			}
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(delay)
+		case <-timer.C:
+			return paths
+		}
+	}
+}
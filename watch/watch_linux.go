@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// dirMask is the mask of the events watched in directories.
+	dirMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+		syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ONLYDIR
+)
+
+// watch is a directory watched by a Watcher.
+type watch struct {
+	dir string
+	// files are the names of the watched files of the directory, or nil if
+	// the whole tree is watched.
+	files map[string]bool
+}
+
+// Watcher watches files and directory trees using inotify.
+type Watcher struct {
+	file    *os.File
+	events  chan string
+	errors  chan error
+	done    chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	watches map[int32]*watch
+	dirs    map[string]int32
+}
+
+// New returns a new Watcher, that does not watch any file yet.
+func New() (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init: %w", err)
+	}
+	w := &Watcher{
+		// As fd is non-blocking, reads use the runtime poller and are
+		// interrupted by Close.
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		events:  make(chan string),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+		watches: make(map[int32]*watch),
+		dirs:    make(map[string]int32),
+	}
+	go w.read()
+	return w, nil
+}
+
+// Events returns the channel on which the paths of the changed files are
+// sent. It is closed when the Watcher is closed.
+func (w *Watcher) Events() <-chan string {
+	return w.events
+}
+
+// Errors returns the channel on which the error that stopped the Watcher, if
+// any, is sent.
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Add watches the file or the directory tree located at path. The changes of
+// a file are detected through its parent directory, so that the files
+// replaced by a rename are still watched.
+func (w *Watcher) Add(path string) error {
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if info.IsDir() {
+		return w.addTree(path)
+	}
+	dir, name := filepath.Split(path)
+	dir = filepath.Clean(dir)
+	if wd, ok := w.dirs[dir]; ok {
+		if files := w.watches[wd].files; files != nil {
+			files[name] = true
+		}
+		return nil
+	}
+	return w.addDir(dir, map[string]bool{name: true})
+}
+
+// addTree watches the directory tree located at root.
+func (w *Watcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return w.addDir(path, nil)
+	})
+}
+
+// addDir watches the directory located at dir, restricted to the given files
+// if they are not nil.
+func (w *Watcher) addDir(dir string, files map[string]bool) error {
+	wd, err := syscall.InotifyAddWatch(int(w.file.Fd()), dir, dirMask)
+	if err != nil {
+		return &fs.PathError{Op: "inotify add watch", Path: dir, Err: err}
+	}
+	if old, ok := w.watches[int32(wd)]; ok && old.files != nil && files != nil {
+		for name := range old.files {
+			files[name] = true
+		}
+	}
+	w.watches[int32(wd)] = &watch{dir, files}
+	w.dirs[dir] = int32(wd)
+	return nil
+}
+
+// Close stops watching the files, and closes the Events channel.
+func (w *Watcher) Close() error {
+	err := os.ErrClosed
+	w.once.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
+
+// read reads the inotify events and sends the paths of the watched files
+// that changed, until the Watcher is closed.
+func (w *Watcher) read() {
+	defer close(w.events)
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if errors.Is(err, os.ErrClosed) {
+			return
+		}
+		if err != nil {
+			w.errors <- fmt.Errorf("read inotify events: %w", err)
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			offset = nameStart + int(event.Len)
+			name := string(buf[nameStart:offset])
+			for i := 0; i < len(name); i++ {
+				if name[i] == 0 {
+					name = name[:i]
+					break
+				}
+			}
+			if path, ok := w.handle(event.Wd, event.Mask, name); ok {
+				select {
+				case w.events <- path:
+				case <-w.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle updates the watches according to the event, and returns the path of
+// the changed file, if it is watched.
+func (w *Watcher) handle(wd int32, mask uint32, name string) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	watch, ok := w.watches[wd]
+	if !ok {
+		return "", false
+	}
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.watches, wd)
+		if w.dirs[watch.dir] == wd {
+			delete(w.dirs, watch.dir)
+		}
+		return "", false
+	}
+	if watch.files != nil {
+		return filepath.Join(watch.dir, name), watch.files[name]
+	}
+	path := filepath.Join(watch.dir, name)
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		// The files created before the watch is added are missed, but
+		// the event of the new directory itself is still reported.
+		w.addTree(path)
+	}
+	return path, true
+}
@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+//go:build !linux
+
+package watch
+
+// Watcher watches files and directory trees.
+type Watcher struct{}
+
+// New returns ErrUnsupported.
+func New() (*Watcher, error) {
+	return nil, ErrUnsupported
+}
+
+func (w *Watcher) Events() <-chan string { return nil }
+func (w *Watcher) Errors() <-chan error  { return nil }
+func (w *Watcher) Add(path string) error { return ErrUnsupported }
+func (w *Watcher) Close() error          { return nil }
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2024 Nicolas Peugnet <nicolas@club1.fr>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package watch_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/n-peugnet/lintian-ssg/watch"
+)
+
+func newWatcher(t *testing.T, paths ...string) *watch.Watcher {
+	w, err := watch.New()
+	if errors.Is(err, watch.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	for _, path := range paths {
+		if err := w.Add(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectEvent(t *testing.T, w *watch.Watcher, expected string) {
+	select {
+	case path := <-w.Events():
+		if path != expected {
+			t.Errorf("expected event for %q, got: %q", expected, path)
+		}
+	case err := <-w.Errors():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event received for %q", expected)
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	watched := filepath.Join(dir, "watched.json")
+	writeFile(t, watched, "{}")
+	w := newWatcher(t, watched)
+
+	writeFile(t, filepath.Join(dir, "other.json"), "{}")
+	writeFile(t, watched, "[]")
+	expectEvent(t, w, watched)
+
+	// Editors often replace the file by renaming a new one.
+	tmp := filepath.Join(dir, "watched.json.tmp")
+	writeFile(t, tmp, "[{}]")
+	if err := os.Rename(tmp, watched); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, watched)
+}
+
+func TestTree(t *testing.T) {
+	dir := t.TempDir()
+	w := newWatcher(t, dir)
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, w, sub)
+	// Wait for the new directory to be watched.
+	time.Sleep(50 * time.Millisecond)
+	file := filepath.Join(sub, "test.tag")
+	writeFile(t, file, "Tag: test")
+	expectEvent(t, w, file)
+	expectEvent(t, w, file)
+}
+
+func TestClose(t *testing.T) {
+	w := newWatcher(t, t.TempDir())
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-w.Events():
+		if ok {
+			t.Error("expected events channel to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("events channel not closed")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	events := make(chan string)
+	go func() {
+		for _, path := range []string{"a", "b", "a"} {
+			events <- path
+		}
+		close(events)
+	}()
+	paths := watch.Batch(events, time.Second)
+	if len(paths) != 2 || paths[0] != "a" || paths[1] != "b" {
+		t.Errorf("unexpected paths: %v", paths)
+	}
+	if paths := watch.Batch(events, time.Second); paths != nil {
+		t.Errorf("expected nil, got: %v", paths)
+	}
+}